@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage/sqlite"
+)
+
+// runAddUser implements the "adduser" admin CLI used to seed the users
+// table: `imap-server adduser <username> <password> [mailbox-root]`. It
+// opens its own Backend rather than going through Server because it runs
+// standalone, without starting the listeners. Only the sqlite backend has
+// a users table; there is no maildir equivalent (see
+// storage/maildir/auth.go).
+func runAddUser(args []string) {
+	fs := flag.NewFlagSet("adduser", flag.ExitOnError)
+	dbPath := fs.String("db", defaultSQLiteDB, "sqlite database file")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) < 2 {
+		fmt.Println("usage: imap-server adduser [-db path] <username> <password> [mailbox-root]")
+		os.Exit(1)
+	}
+	username, password := rest[0], rest[1]
+	var mailboxRoot string
+	if len(rest) > 2 {
+		mailboxRoot = rest[2]
+	}
+
+	backend, err := sqlite.Open(*dbPath)
+	if err != nil {
+		log.Fatal("Failed to open database:", err)
+	}
+	defer backend.Close()
+
+	if err := backend.AddUser(username, password, mailboxRoot); err != nil {
+		log.Fatal("Failed to create user:", err)
+	}
+
+	fmt.Printf("user %q created/updated\n", username)
+}
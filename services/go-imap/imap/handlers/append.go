@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+)
+
+// handleAppend implements APPEND per RFC 3501 6.3.11:
+//
+//	tag APPEND mailbox [(flags)] [date-time] {octet-count}
+//
+// The command line is tokenized with proto.TokenizeIMAPLine (rather than
+// strings.Fields) so that the parenthesized flag list and the quoted
+// date-time string survive intact. Once the literal's octet count is
+// known, the message bytes are read directly off the connection.
+func (s *Server) handleAppend(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	tokens := proto.TokenizeIMAPLine(cmd.Line)
+	if len(tokens) < 3 {
+		w.Sendf("%s BAD APPEND requires a mailbox and message literal", cmd.Tag)
+		return
+	}
+
+	folder := quoted(tokens[2])
+	idx := 3
+
+	var flags []string
+	if idx < len(tokens) && strings.HasPrefix(tokens[idx], "(") {
+		flags = strings.Fields(strings.Trim(tokens[idx], "()"))
+		idx++
+	}
+
+	internalDate := time.Now()
+	if idx < len(tokens) && !strings.HasPrefix(tokens[idx], "{") {
+		if d, err := time.Parse("02-Jan-2006 15:04:05 -0700", tokens[idx]); err == nil {
+			internalDate = d
+		}
+		idx++
+	}
+
+	if idx >= len(tokens) {
+		w.Sendf("%s BAD APPEND requires a message literal", cmd.Tag)
+		return
+	}
+	octets, nonSync, ok := proto.ParseLiteralSpec(tokens[idx])
+	if !ok {
+		w.Sendf("%s BAD Invalid literal octet count", cmd.Tag)
+		return
+	}
+
+	if _, err := s.Backend.Status(folder); err != nil {
+		w.Sendf("%s NO [TRYCREATE] Mailbox does not exist", cmd.Tag)
+		return
+	}
+
+	if !nonSync {
+		w.Send("+ Ready for literal data")
+	}
+
+	raw, err := proto.ReadExactly(client.Conn, octets)
+	if err != nil {
+		w.Sendf("%s NO Failed to read message literal", cmd.Tag)
+		return
+	}
+
+	uid, uidValidity, err := s.Backend.Append(folder, raw, flags, internalDate)
+	if err != nil {
+		w.Sendf("%s NO APPEND failed", cmd.Tag)
+		return
+	}
+	s.publishMailboxCounts(folder)
+
+	w.Sendf("%s OK [APPENDUID %d %d] APPEND completed", cmd.Tag, uidValidity, uid)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+)
+
+// handleLogin implements LOGIN (RFC 3501 6.2.3) against Backend.Authenticate.
+func (s *Server) handleLogin(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if len(cmd.Parts) < 4 {
+		w.Sendf("%s BAD LOGIN requires username and password", cmd.Tag)
+		return
+	}
+	if s.RequireTLS && !client.Secure {
+		w.Sendf("%s NO [PRIVACYREQUIRED] LOGIN disabled until STARTTLS", cmd.Tag)
+		return
+	}
+	username := quoted(cmd.Parts[2])
+	password := quoted(cmd.Parts[3])
+	if !s.Backend.Authenticate(username, password) {
+		w.Sendf("%s NO LOGIN failed", cmd.Tag)
+		return
+	}
+	client.Authenticate(username)
+	w.Sendf("%s OK LOGIN completed", cmd.Tag)
+}
+
+// handleAuthenticate implements AUTHENTICATE (RFC 3501 6.2.2) with the
+// PLAIN (RFC 4616) and LOGIN SASL mechanisms, each driven by "+ "
+// continuation lines carrying base64-encoded challenges and responses.
+func (s *Server) handleAuthenticate(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if len(cmd.Parts) < 3 {
+		w.Sendf("%s BAD AUTHENTICATE requires a mechanism", cmd.Tag)
+		return
+	}
+	if s.RequireTLS && !client.Secure {
+		w.Sendf("%s NO [PRIVACYREQUIRED] AUTHENTICATE disabled until STARTTLS", cmd.Tag)
+		return
+	}
+
+	switch strings.ToUpper(cmd.Parts[2]) {
+	case "PLAIN":
+		s.authenticatePlain(w, client, cmd.Tag)
+	case "LOGIN":
+		s.authenticateLogin(w, client, cmd.Tag)
+	default:
+		w.Sendf("%s NO Unsupported SASL mechanism: %s", cmd.Tag, cmd.Parts[2])
+	}
+}
+
+func (s *Server) authenticatePlain(w *proto.Writer, client *session.Client, tag string) {
+	w.Send("+ ")
+	line, err := proto.ReadLine(client.Conn)
+	if err != nil {
+		w.Sendf("%s NO AUTHENTICATE failed", tag)
+		return
+	}
+	payload, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		w.Sendf("%s BAD Invalid base64", tag)
+		return
+	}
+	// authzid NUL authcid NUL password
+	fields := strings.SplitN(string(payload), "\x00", 3)
+	if len(fields) != 3 {
+		w.Sendf("%s BAD Malformed PLAIN response", tag)
+		return
+	}
+	s.finishAuthenticate(w, client, tag, fields[1], fields[2])
+}
+
+func (s *Server) authenticateLogin(w *proto.Writer, client *session.Client, tag string) {
+	w.Send("+ " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+	userLine, err := proto.ReadLine(client.Conn)
+	if err != nil {
+		w.Sendf("%s NO AUTHENTICATE failed", tag)
+		return
+	}
+	username, err := base64.StdEncoding.DecodeString(userLine)
+	if err != nil {
+		w.Sendf("%s BAD Invalid base64", tag)
+		return
+	}
+
+	w.Send("+ " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+	passLine, err := proto.ReadLine(client.Conn)
+	if err != nil {
+		w.Sendf("%s NO AUTHENTICATE failed", tag)
+		return
+	}
+	password, err := base64.StdEncoding.DecodeString(passLine)
+	if err != nil {
+		w.Sendf("%s BAD Invalid base64", tag)
+		return
+	}
+
+	s.finishAuthenticate(w, client, tag, string(username), string(password))
+}
+
+func (s *Server) finishAuthenticate(w *proto.Writer, client *session.Client, tag, username, password string) {
+	if !s.Backend.Authenticate(username, password) {
+		w.Sendf("%s NO AUTHENTICATE failed", tag)
+		return
+	}
+	client.Authenticate(username)
+	w.Sendf("%s OK AUTHENTICATE completed", tag)
+}
+
+// handleStartTLS implements STARTTLS (RFC 3501 6.2.1): once the tagged OK
+// is sent, client.Conn is wrapped in a TLS server handshake in place.
+func (s *Server) handleStartTLS(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if client.Secure {
+		w.Sendf("%s BAD TLS already active", cmd.Tag)
+		return
+	}
+	if s.TLSConfig == nil {
+		w.Sendf("%s NO STARTTLS not available", cmd.Tag)
+		return
+	}
+
+	w.Sendf("%s OK Begin TLS negotiation now", cmd.Tag)
+
+	tlsConn := tls.Server(client.Conn, s.TLSConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return
+	}
+	client.Conn = tlsConn
+	client.Secure = true
+}
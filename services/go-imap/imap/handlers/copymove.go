@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/notify"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+)
+
+// handleCopy implements COPY (RFC 3501 6.4.7).
+func (s *Server) handleCopy(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.copyOrMove(w, client, cmd.Tag, cmd.Parts, false, false)
+}
+
+// handleUIDCopy implements UID COPY (RFC 3501 6.4.8). cmd.Parts is the full
+// "tag UID COPY <set> <mailbox>" line, so it's sliced down to line up with
+// the plain COPY indices before reaching copyOrMove.
+func (s *Server) handleUIDCopy(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.copyOrMove(w, client, cmd.Tag, cmd.Parts[1:], true, false)
+}
+
+// handleMove implements MOVE (RFC 6851 3.1).
+func (s *Server) handleMove(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.copyOrMove(w, client, cmd.Tag, cmd.Parts, false, true)
+}
+
+// handleUIDMove implements UID MOVE (RFC 6851 3.2).
+func (s *Server) handleUIDMove(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.copyOrMove(w, client, cmd.Tag, cmd.Parts[1:], true, true)
+}
+
+// copyOrMove is the shared implementation behind COPY/UID COPY and
+// MOVE/UID MOVE: both copy the messages named by set into the destination
+// mailbox, and MOVE additionally removes the originals and reports their
+// expunging, per RFC 6851 3.1 ("MOVE is the same as COPY followed by
+// EXPUNGE of the copied messages, except the copy and expunge are atomic").
+func (s *Server) copyOrMove(w *proto.Writer, client *session.Client, tag string, parts []string, useUID, move bool) {
+	cmdName := "COPY"
+	if move {
+		cmdName = "MOVE"
+	}
+	srcFolder := client.SelectedMailbox()
+	if srcFolder == "" {
+		w.Sendf("%s NO No folder selected", tag)
+		return
+	}
+	if len(parts) < 4 {
+		w.Sendf("%s BAD %s requires a sequence set and mailbox", tag, cmdName)
+		return
+	}
+
+	set := parts[2]
+	destFolder := strings.Trim(parts[3], "\"")
+
+	if move {
+		copyResult, expunged, err := s.Backend.Move(srcFolder, destFolder, set, useUID)
+		if err != nil {
+			w.Sendf("%s NO [TRYCREATE] %s", tag, err)
+			return
+		}
+		if len(copyResult.SrcUIDs) == 0 {
+			w.Sendf("%s NO No messages matched %s", tag, cmdName)
+			return
+		}
+		s.publishMailboxCounts(destFolder)
+		for _, ex := range expunged {
+			w.Sendf("* %d EXPUNGE", ex.Seq)
+			s.Notifier.Publish(srcFolder, notify.MailboxEvent{Folder: srcFolder, Kind: "EXPUNGE", Seq: ex.Seq})
+		}
+		s.publishMailboxCounts(srcFolder)
+		w.Sendf("%s OK [COPYUID %d %s %s] %s completed",
+			tag, copyResult.DestUIDValidity, joinUIDs(copyResult.SrcUIDs), joinUIDs(copyResult.DestUIDs), cmdName)
+		return
+	}
+
+	copyResult, err := s.Backend.Copy(srcFolder, destFolder, set, useUID)
+	if err != nil {
+		w.Sendf("%s NO [TRYCREATE] %s", tag, err)
+		return
+	}
+	if len(copyResult.SrcUIDs) == 0 {
+		w.Sendf("%s NO No messages matched %s", tag, cmdName)
+		return
+	}
+	s.publishMailboxCounts(destFolder)
+	w.Sendf("%s OK [COPYUID %d %s %s] %s completed",
+		tag, copyResult.DestUIDValidity, joinUIDs(copyResult.SrcUIDs), joinUIDs(copyResult.DestUIDs), cmdName)
+}
+
+func joinUIDs(uids []int) string {
+	parts := make([]string, len(uids))
+	for i, u := range uids {
+		parts[i] = strconv.Itoa(u)
+	}
+	return strings.Join(parts, ",")
+}
+
+// handleExpunge implements EXPUNGE (RFC 3501 6.4.3): permanently removes
+// all messages in the selected mailbox flagged \Deleted, reporting each as
+// an untagged EXPUNGE in descending sequence order so earlier removals
+// don't shift the sequence numbers of ones still to be reported.
+func (s *Server) handleExpunge(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	folder := client.SelectedMailbox()
+	if folder == "" {
+		w.Sendf("%s NO No folder selected", cmd.Tag)
+		return
+	}
+
+	expunged, err := s.Backend.Expunge(folder, "1:*", false)
+	if err != nil {
+		w.Sendf("%s NO EXPUNGE failed", cmd.Tag)
+		return
+	}
+	for _, ex := range expunged {
+		w.Sendf("* %d EXPUNGE", ex.Seq)
+		s.Notifier.Publish(folder, notify.MailboxEvent{Folder: folder, Kind: "EXPUNGE", Seq: ex.Seq})
+	}
+	if len(expunged) > 0 {
+		s.publishMailboxCounts(folder)
+	}
+
+	w.Sendf("%s OK EXPUNGE completed", cmd.Tag)
+}
+
+// handleUIDExpunge implements UID EXPUNGE (RFC 4315 2.1): like EXPUNGE, but
+// restricted to the \Deleted messages named by the given UID set.
+func (s *Server) handleUIDExpunge(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	folder := client.SelectedMailbox()
+	if folder == "" {
+		w.Sendf("%s NO No folder selected", cmd.Tag)
+		return
+	}
+	if len(cmd.Parts) < 4 {
+		w.Sendf("%s BAD UID EXPUNGE requires a UID set", cmd.Tag)
+		return
+	}
+
+	expunged, err := s.Backend.Expunge(folder, cmd.Parts[3], true)
+	if err != nil {
+		w.Sendf("%s BAD %s", cmd.Tag, err)
+		return
+	}
+	for _, ex := range expunged {
+		w.Sendf("* %d EXPUNGE", ex.Seq)
+	}
+
+	w.Sendf("%s OK UID EXPUNGE completed", cmd.Tag)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+)
+
+// handleFetch implements FETCH (RFC 3501 6.4.5).
+func (s *Server) handleFetch(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if client.SelectedMailbox() == "" {
+		w.Sendf("%s NO No folder selected", cmd.Tag)
+		return
+	}
+	if len(cmd.Parts) < 4 {
+		w.Sendf("%s BAD FETCH requires sequence and items", cmd.Tag)
+		return
+	}
+	s.runFetch(w, cmd.Tag, "FETCH", client.SelectedMailbox(), cmd.Parts[2], strings.Join(cmd.Parts[3:], " "), false)
+}
+
+// handleUIDFetch implements UID FETCH (RFC 3501 6.4.8).
+func (s *Server) handleUIDFetch(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if client.SelectedMailbox() == "" {
+		w.Sendf("%s NO No folder selected", cmd.Tag)
+		return
+	}
+	if len(cmd.Parts) < 5 {
+		w.Sendf("%s BAD UID FETCH requires sequence and items", cmd.Tag)
+		return
+	}
+	s.runFetch(w, cmd.Tag, "UID FETCH", client.SelectedMailbox(), cmd.Parts[3], strings.Join(cmd.Parts[4:], " "), true)
+}
+
+// runFetch is the shared core of FETCH and UID FETCH: it asks the backend
+// to render items against every message named by seqset and writes one
+// "* seq FETCH (...)" response per message.
+func (s *Server) runFetch(w *proto.Writer, tag, cmdName, folder, seqset, items string, useUID bool) {
+	results, err := s.Backend.Fetch(folder, seqset, items, useUID)
+	if err != nil {
+		w.Sendf("%s BAD %s", tag, err)
+		return
+	}
+	for _, r := range results {
+		w.SendFetch(r.Seq, r.Fields)
+	}
+	w.Sendf("%s OK %s completed", tag, cmdName)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+)
+
+// handleIdle implements IDLE per RFC 2177: after the "+ idling" continuation,
+// the connection is subscribed to the selected folder's notifier and every
+// EXISTS/RECENT/EXPUNGE/FETCH event published by other commands (APPEND,
+// COPY, MOVE, STORE, EXPUNGE) is relayed as an untagged response until the
+// client sends "DONE".
+func (s *Server) handleIdle(w *proto.Writer, client *session.Client, tag string) {
+	folder := client.SelectedMailbox()
+	if folder == "" {
+		w.Sendf("%s NO No folder selected", tag)
+		return
+	}
+
+	events, unsubscribe := s.Notifier.Subscribe(folder)
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 4096)
+		for {
+			client.Conn.SetReadDeadline(time.Now().Add(30 * time.Minute))
+			n, err := client.Conn.Read(buf)
+			if err != nil {
+				return
+			}
+			if strings.EqualFold(strings.TrimSpace(string(buf[:n])), "DONE") {
+				return
+			}
+		}
+	}()
+
+	w.Send("+ idling")
+
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Kind {
+			case "EXISTS":
+				w.Sendf("* %d EXISTS", ev.Seq)
+			case "RECENT":
+				w.Sendf("* %d RECENT", ev.Seq)
+			case "EXPUNGE":
+				w.Sendf("* %d EXPUNGE", ev.Seq)
+			case "FETCH":
+				w.Sendf("* %d FETCH (FLAGS (%s) UID %d)", ev.Seq, ev.Flags, ev.UID)
+			}
+		case <-done:
+			w.Sendf("%s OK IDLE completed", tag)
+			return
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// handleList implements LIST (RFC 3501 6.3.8).
+func (s *Server) handleList(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.listMailboxes(w, cmd, "LIST", false)
+}
+
+// handleLsub implements LSUB (RFC 3501 6.3.9): like LIST, but restricted to
+// subscribed mailboxes.
+func (s *Server) handleLsub(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.listMailboxes(w, cmd, "LSUB", true)
+}
+
+func (s *Server) listMailboxes(w *proto.Writer, cmd proto.Command, cmdName string, subscribedOnly bool) {
+	if len(cmd.Parts) < 4 {
+		w.Sendf("%s BAD %s requires reference and mailbox pattern", cmd.Tag, cmdName)
+		return
+	}
+
+	reference := quoted(cmd.Parts[2])
+	pattern := quoted(cmd.Parts[3])
+
+	if pattern == "" {
+		// RFC 3501 6.3.8: an empty mailbox name means "return the
+		// hierarchy delimiter and the root name of reference".
+		w.Send("* LIST (\\Noselect) \"/\" \"\"")
+		w.Sendf("%s OK %s completed", cmd.Tag, cmdName)
+		return
+	}
+
+	mailboxes, err := s.Backend.ListMailboxes()
+	if err != nil {
+		w.Sendf("%s NO %s failed", cmd.Tag, cmdName)
+		return
+	}
+
+	full := reference + pattern
+	for _, m := range mailboxes {
+		if subscribedOnly && !m.Subscribed {
+			continue
+		}
+		if !storage.MatchMailboxPattern(full, m.Name) {
+			continue
+		}
+		attrs := m.Attributes
+		if attrs == "" {
+			attrs = "\\Unmarked"
+		}
+		w.Sendf("* %s (%s) \"%s\" \"%s\"", cmdName, attrs, m.Delimiter, m.Name)
+	}
+	w.Sendf("%s OK %s completed", cmd.Tag, cmdName)
+}
+
+// handleSelect implements SELECT/EXAMINE (RFC 3501 6.3.1/6.3.2).
+func (s *Server) handleSelect(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if len(cmd.Parts) < 3 {
+		w.Sendf("%s BAD %s requires folder name", cmd.Tag, cmd.Name)
+		return
+	}
+	folder := quoted(cmd.Parts[2])
+
+	status, err := s.Backend.Select(folder)
+	if err != nil {
+		client.Deselect()
+		w.Sendf("%s NO Mailbox does not exist", cmd.Tag)
+		return
+	}
+
+	readOnly := cmd.Name == "EXAMINE"
+	client.Select(folder, readOnly)
+
+	w.Sendf("* %d EXISTS", status.Messages)
+	w.Sendf("* %d RECENT", status.Recent)
+	w.Sendf("* OK [UIDVALIDITY %d] UID validity status", status.UIDValidity)
+	w.Sendf("* OK [UIDNEXT %d] Predicted next UID", status.UIDNext)
+	w.Send("* FLAGS (\\Answered \\Flagged \\Deleted \\Seen \\Draft)")
+	w.Send("* OK [PERMANENTFLAGS (\\Answered \\Flagged \\Deleted \\Seen \\Draft \\*)] Flags permitted")
+
+	if readOnly {
+		w.Sendf("%s OK [READ-ONLY] EXAMINE completed", cmd.Tag)
+	} else {
+		w.Sendf("%s OK [READ-WRITE] SELECT completed", cmd.Tag)
+	}
+}
+
+// handleStatus implements STATUS (RFC 3501 6.3.10). The requested item
+// list is accepted but ignored, same as before this refactor: every
+// counter is always reported.
+func (s *Server) handleStatus(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if len(cmd.Parts) < 4 {
+		w.Sendf("%s BAD STATUS requires folder and items", cmd.Tag)
+		return
+	}
+	folder := quoted(cmd.Parts[2])
+
+	status, err := s.Backend.Status(folder)
+	if err != nil {
+		w.Sendf("%s NO Mailbox does not exist", cmd.Tag)
+		return
+	}
+
+	w.Sendf("* STATUS \"%s\" (MESSAGES %d RECENT 0 UIDNEXT %d UIDVALIDITY %d UNSEEN %d)",
+		folder, status.Messages, status.UIDNext, status.UIDValidity, status.Unseen)
+	w.Sendf("%s OK STATUS completed", cmd.Tag)
+}
+
+// handleCreate implements CREATE (RFC 3501 6.3.3).
+func (s *Server) handleCreate(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if len(cmd.Parts) < 3 {
+		w.Sendf("%s BAD CREATE requires a mailbox name", cmd.Tag)
+		return
+	}
+	folder := quoted(cmd.Parts[2])
+	if err := s.Backend.CreateMailbox(folder); err != nil {
+		w.Sendf("%s NO %s", cmd.Tag, mailboxErrorMessage(err, "CREATE failed"))
+		return
+	}
+	w.Sendf("%s OK CREATE completed", cmd.Tag)
+}
+
+// handleDelete implements DELETE (RFC 3501 6.3.4).
+func (s *Server) handleDelete(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if len(cmd.Parts) < 3 {
+		w.Sendf("%s BAD DELETE requires a mailbox name", cmd.Tag)
+		return
+	}
+	folder := quoted(cmd.Parts[2])
+	if err := s.Backend.DeleteMailbox(folder); err != nil {
+		w.Sendf("%s NO %s", cmd.Tag, mailboxErrorMessage(err, "DELETE failed"))
+		return
+	}
+	if client.SelectedMailbox() == folder {
+		client.Deselect()
+	}
+	w.Sendf("%s OK DELETE completed", cmd.Tag)
+}
+
+// handleRename implements RENAME (RFC 3501 6.3.5).
+func (s *Server) handleRename(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	if len(cmd.Parts) < 4 {
+		w.Sendf("%s BAD RENAME requires old and new mailbox names", cmd.Tag)
+		return
+	}
+	oldName := quoted(cmd.Parts[2])
+	newName := quoted(cmd.Parts[3])
+	if err := s.Backend.RenameMailbox(oldName, newName); err != nil {
+		w.Sendf("%s NO %s", cmd.Tag, mailboxErrorMessage(err, "RENAME failed"))
+		return
+	}
+	if client.SelectedMailbox() == oldName {
+		client.Select(newName, client.ReadOnly())
+	}
+	w.Sendf("%s OK RENAME completed", cmd.Tag)
+}
+
+// setSubscribed implements SUBSCRIBE and UNSUBSCRIBE (RFC 3501 6.3.6/6.3.7).
+func (s *Server) setSubscribed(w *proto.Writer, client *session.Client, cmd proto.Command, subscribed bool) {
+	cmdName := "SUBSCRIBE"
+	if !subscribed {
+		cmdName = "UNSUBSCRIBE"
+	}
+	if len(cmd.Parts) < 3 {
+		w.Sendf("%s BAD %s requires a mailbox name", cmd.Tag, cmdName)
+		return
+	}
+	folder := quoted(cmd.Parts[2])
+	if err := s.Backend.SetSubscribed(folder, subscribed); err != nil {
+		w.Sendf("%s NO %s", cmd.Tag, mailboxErrorMessage(err, cmdName+" failed"))
+		return
+	}
+	w.Sendf("%s OK %s completed", cmd.Tag, cmdName)
+}
+
+// mailboxErrorMessage translates the storage sentinel errors into the
+// specific NO text clients have always seen from this server, falling back
+// to fallback for anything backend-specific.
+func mailboxErrorMessage(err error, fallback string) string {
+	switch err {
+	case storage.ErrMailboxNotFound:
+		return "Mailbox does not exist"
+	case storage.ErrMailboxExists:
+		return "Mailbox already exists"
+	case storage.ErrInboxUndeletable:
+		return "INBOX cannot be deleted"
+	default:
+		return fallback
+	}
+}
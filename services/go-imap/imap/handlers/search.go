@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// handleSearch implements SEARCH (RFC 3501 6.4.4).
+func (s *Server) handleSearch(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.runSearch(w, client, cmd.Tag, cmd.Parts[2:], false)
+}
+
+// handleUIDSearch implements UID SEARCH (RFC 3501 6.4.8).
+func (s *Server) handleUIDSearch(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	s.runSearch(w, client, cmd.Tag, cmd.Parts[3:], true)
+}
+
+// runSearch implements SEARCH and UID SEARCH: it parses criteriaTokens
+// into a storage.SearchCriterion, asks the backend to evaluate it against
+// the selected folder, and reports either sequence numbers or UIDs
+// depending on useUID.
+func (s *Server) runSearch(w *proto.Writer, client *session.Client, tag string, criteriaTokens []string, useUID bool) {
+	cmdName := "SEARCH"
+	if useUID {
+		cmdName = "UID SEARCH"
+	}
+
+	folder := client.SelectedMailbox()
+	if folder == "" {
+		w.Sendf("%s NO No folder selected", tag)
+		return
+	}
+	if len(criteriaTokens) == 0 {
+		w.Sendf("%s BAD %s requires search criteria", tag, cmdName)
+		return
+	}
+
+	criterion, err := storage.ParseSearchCriteria(criteriaTokens)
+	if err != nil {
+		w.Sendf("%s BAD %s", tag, err)
+		return
+	}
+
+	matches, err := s.Backend.Search(folder, criterion)
+	if err != nil {
+		w.Sendf("%s NO Search failed", tag)
+		return
+	}
+
+	var results []string
+	for _, msg := range matches {
+		if useUID {
+			results = append(results, strconv.Itoa(msg.UID))
+		} else {
+			results = append(results, strconv.Itoa(msg.Seq))
+		}
+	}
+
+	w.Send("* SEARCH " + strings.Join(results, " "))
+	w.Sendf("%s OK %s completed", tag, cmdName)
+}
@@ -0,0 +1,206 @@
+// Package handlers implements every IMAP command this server supports, one
+// file per command (or closely related command group), each driven
+// through the storage.Backend interface rather than any one backend's
+// storage details. Server ties together imap/proto (wire syntax),
+// imap/session (per-connection state) and imap/notify (IDLE push) around
+// a Backend.
+package handlers
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/notify"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Server holds everything a connection's command dispatch needs: the
+// storage backend every handler reads and writes through, the IDLE
+// notifier, and the TLS configuration for STARTTLS/implicit-TLS.
+type Server struct {
+	Backend    storage.Backend
+	Notifier   *notify.Notifier
+	TLSConfig  *tls.Config
+	RequireTLS bool
+}
+
+func New(backend storage.Backend, notifier *notify.Notifier, tlsConfig *tls.Config, requireTLS bool) *Server {
+	return &Server{Backend: backend, Notifier: notifier, TLSConfig: tlsConfig, RequireTLS: requireTLS}
+}
+
+// capabilityString builds the CAPABILITY list for the greeting and the
+// CAPABILITY command, varying with the connection's current TLS state:
+// STARTTLS is only offered pre-TLS, and LOGINDISABLED is added on top of it
+// once RequireTLS forbids plaintext LOGIN/AUTHENTICATE.
+func (s *Server) capabilityString(secure bool) string {
+	caps := "IMAP4rev1 IDLE UIDPLUS MOVE AUTH=PLAIN AUTH=LOGIN THREAD=ORDEREDSUBJECT THREAD=REFERENCES"
+	if !secure {
+		caps += " STARTTLS"
+		if s.RequireTLS {
+			caps += " LOGINDISABLED"
+		}
+	}
+	return caps
+}
+
+// Serve drives one client connection to completion: it sends the greeting,
+// then loops reading and dispatching commands until LOGOUT or the
+// connection closes. isSecure is true for connections accepted on the
+// implicit-TLS listener (993); plaintext (143) connections start insecure
+// and may upgrade in place via STARTTLS.
+func (s *Server) Serve(conn net.Conn, isSecure bool) {
+	defer conn.Close()
+
+	client := session.New(conn, isSecure)
+	w := proto.NewWriter(conn)
+
+	w.Sendf("* OK [CAPABILITY %s] SQLite IMAP server ready", s.capabilityString(client.Secure))
+
+	for {
+		cmd, ok, err := proto.ReadCommand(client.Conn)
+		if err != nil {
+			return
+		}
+		if !ok {
+			if cmd.Line != "" {
+				w.Send("* BAD Invalid command format")
+			}
+			continue
+		}
+
+		if s.RequireTLS && !client.Secure && cmd.Name != "CAPABILITY" && cmd.Name != "STARTTLS" && cmd.Name != "LOGOUT" {
+			w.Sendf("%s BAD Command not permitted before STARTTLS", cmd.Tag)
+			continue
+		}
+
+		if cmd.Name == "UID" && len(cmd.Parts) > 2 {
+			s.dispatchUID(w, client, cmd)
+			continue
+		}
+		if cmd.Name == "IDLE" {
+			if !client.IsAuthenticated() {
+				w.Sendf("%s NO Please authenticate first", cmd.Tag)
+				continue
+			}
+			s.handleIdle(w, client, cmd.Tag)
+			continue
+		}
+
+		if done := s.dispatch(w, client, cmd); done {
+			return
+		}
+
+		// STARTTLS swaps the underlying connection; pick up the upgraded
+		// one (and its writer) for every subsequent read.
+		if client.Conn != conn {
+			conn = client.Conn
+			w = proto.NewWriter(conn)
+		}
+	}
+}
+
+// dispatch routes one non-UID command to its handler, returning true once
+// the connection should close (LOGOUT).
+func (s *Server) dispatch(w *proto.Writer, client *session.Client, cmd proto.Command) bool {
+	authed := map[string]func(){
+		"LIST":        func() { s.handleList(w, client, cmd) },
+		"LSUB":        func() { s.handleLsub(w, client, cmd) },
+		"SELECT":      func() { s.handleSelect(w, client, cmd) },
+		"EXAMINE":     func() { s.handleSelect(w, client, cmd) },
+		"FETCH":       func() { s.handleFetch(w, client, cmd) },
+		"SEARCH":      func() { s.handleSearch(w, client, cmd) },
+		"THREAD":      func() { s.handleThread(w, client, cmd) },
+		"STATUS":      func() { s.handleStatus(w, client, cmd) },
+		"CREATE":      func() { s.handleCreate(w, client, cmd) },
+		"DELETE":      func() { s.handleDelete(w, client, cmd) },
+		"RENAME":      func() { s.handleRename(w, client, cmd) },
+		"SUBSCRIBE":   func() { s.setSubscribed(w, client, cmd, true) },
+		"UNSUBSCRIBE": func() { s.setSubscribed(w, client, cmd, false) },
+		"COPY":        func() { s.handleCopy(w, client, cmd) },
+		"MOVE":        func() { s.handleMove(w, client, cmd) },
+		"EXPUNGE":     func() { s.handleExpunge(w, client, cmd) },
+	}
+
+	if h, ok := authed[cmd.Name]; ok {
+		if !client.IsAuthenticated() {
+			w.Sendf("%s NO Please authenticate first", cmd.Tag)
+			return false
+		}
+		h()
+		return false
+	}
+
+	switch cmd.Name {
+	case "CAPABILITY":
+		w.Send("* CAPABILITY " + s.capabilityString(client.Secure))
+		w.Sendf("%s OK CAPABILITY completed", cmd.Tag)
+	case "LOGIN":
+		s.handleLogin(w, client, cmd)
+	case "AUTHENTICATE":
+		s.handleAuthenticate(w, client, cmd)
+	case "STARTTLS":
+		s.handleStartTLS(w, client, cmd)
+	case "APPEND":
+		if !client.IsAuthenticated() {
+			w.Sendf("%s NO Please authenticate first", cmd.Tag)
+			return false
+		}
+		s.handleAppend(w, client, cmd)
+	case "NOOP":
+		w.Sendf("%s OK NOOP completed", cmd.Tag)
+	case "LOGOUT":
+		w.Send("* BYE SQLite IMAP server logging out")
+		w.Sendf("%s OK LOGOUT completed", cmd.Tag)
+		return true
+	default:
+		w.Sendf("%s BAD Unknown command: %s", cmd.Tag, cmd.Name)
+	}
+	return false
+}
+
+// dispatchUID routes "tag UID <subcommand> ..." to the UID form of the
+// subcommand it names; unlike the non-UID commands, only a fixed subset of
+// commands have a UID form (RFC 3501 6.4.8).
+func (s *Server) dispatchUID(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	subCmd := strings.ToUpper(cmd.Parts[2])
+	uidAuthed := map[string]func(){
+		"FETCH":   func() { s.handleUIDFetch(w, client, cmd) },
+		"SEARCH":  func() { s.handleUIDSearch(w, client, cmd) },
+		"THREAD":  func() { s.handleUIDThread(w, client, cmd) },
+		"STORE":   func() { s.handleUIDStore(w, client, cmd) },
+		"COPY":    func() { s.handleUIDCopy(w, client, cmd) },
+		"MOVE":    func() { s.handleUIDMove(w, client, cmd) },
+		"EXPUNGE": func() { s.handleUIDExpunge(w, client, cmd) },
+	}
+	h, ok := uidAuthed[subCmd]
+	if !ok {
+		w.Sendf("%s BAD Unknown UID subcommand: %s", cmd.Tag, subCmd)
+		return
+	}
+	if !client.IsAuthenticated() {
+		w.Sendf("%s NO Please authenticate first", cmd.Tag)
+		return
+	}
+	h()
+}
+
+// publishMailboxCounts notifies folder's IDLE-ing clients of its current
+// EXISTS and RECENT counts, per RFC 3501 7.3.1/7.3.2.
+func (s *Server) publishMailboxCounts(folder string) {
+	status, err := s.Backend.Status(folder)
+	if err != nil {
+		return
+	}
+	s.Notifier.Publish(folder, notify.MailboxEvent{Folder: folder, Kind: "EXISTS", Seq: status.Messages})
+	s.Notifier.Publish(folder, notify.MailboxEvent{Folder: folder, Kind: "RECENT", Seq: status.Recent})
+}
+
+// quoted strips one layer of double quotes from an IMAP astring/quoted
+// token, which is all the mailbox and flag arguments this server accepts
+// ever carry.
+func quoted(s string) string {
+	return strings.Trim(s, "\"")
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/notify"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/proto"
+	"github.com/Aravinda-HWK/silver/services/go-imap/imap/session"
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// handleUIDStore implements UID STORE per RFC 3501 6.4.6, supporting the
+// full operator set (FLAGS, +FLAGS, -FLAGS and their .SILENT variants)
+// over an arbitrary flag list. There is no plain (non-UID) STORE command in
+// this server; clients use UID STORE exclusively.
+func (s *Server) handleUIDStore(w *proto.Writer, client *session.Client, cmd proto.Command) {
+	folder := client.SelectedMailbox()
+	if folder == "" {
+		w.Sendf("%s NO No folder selected", cmd.Tag)
+		return
+	}
+	if len(cmd.Parts) < 6 {
+		w.Sendf("%s BAD UID STORE requires sequence, operation, and flags", cmd.Tag)
+		return
+	}
+
+	seqset := cmd.Parts[3]
+	operation := strings.ToUpper(cmd.Parts[4])
+	silent := strings.HasSuffix(operation, ".SILENT")
+	operation = strings.TrimSuffix(operation, ".SILENT")
+
+	flagsStr := strings.Trim(strings.Join(cmd.Parts[5:], " "), "()")
+	var flagList []string
+	if flagsStr != "" {
+		flagList = strings.Fields(flagsStr)
+	}
+
+	var mode storage.StoreMode
+	switch operation {
+	case "FLAGS":
+		mode = storage.StoreSet
+	case "+FLAGS":
+		mode = storage.StoreAdd
+	case "-FLAGS":
+		mode = storage.StoreRemove
+	default:
+		w.Sendf("%s BAD Unknown STORE operation: %s", cmd.Tag, operation)
+		return
+	}
+
+	results, err := s.Backend.Store(folder, seqset, true, mode, flagList)
+	if err != nil {
+		w.Sendf("%s BAD %s", cmd.Tag, err)
+		return
+	}
+
+	for _, r := range results {
+		if !silent {
+			flagsResp := "()"
+			if len(r.Flags) > 0 {
+				flagsResp = fmt.Sprintf("(%s)", strings.Join(r.Flags, " "))
+			}
+			w.Sendf("* %d FETCH (FLAGS %s UID %d)", r.Seq, flagsResp, r.UID)
+		}
+		s.Notifier.Publish(folder, notify.MailboxEvent{
+			Folder: folder, Kind: "FETCH", Seq: r.Seq, UID: r.UID, Flags: strings.Join(r.Flags, " "),
+		})
+	}
+
+	w.Sendf("%s OK UID STORE completed", cmd.Tag)
+}
@@ -0,0 +1,65 @@
+// Package notify is the in-process pub/sub IDLE (RFC 2177) is built on:
+// it fans out mailbox mutation events to every connection currently
+// IDLE-ing on the affected folder. It has no notion of IMAP wire syntax or
+// storage; imap/handlers publishes to it after a successful mutation and
+// subscribes to it while handling IDLE.
+package notify
+
+import "sync"
+
+// MailboxEvent describes a single mutation to a folder that IDLE-ing
+// clients need to be told about.
+type MailboxEvent struct {
+	Folder string
+	Kind   string // "EXISTS", "RECENT", "EXPUNGE", "FETCH"
+	Seq    int
+	UID    int
+	Flags  string
+}
+
+// Notifier is an in-process pub/sub fanning out MailboxEvents to every
+// listener currently subscribed to a given folder.
+type Notifier struct {
+	mu   sync.Mutex
+	subs map[string]map[chan MailboxEvent]bool
+}
+
+func New() *Notifier {
+	return &Notifier{subs: make(map[string]map[chan MailboxEvent]bool)}
+}
+
+// Subscribe registers a new listener for folder and returns its event
+// channel along with an unsubscribe func the caller must invoke when done.
+func (n *Notifier) Subscribe(folder string) (chan MailboxEvent, func()) {
+	ch := make(chan MailboxEvent, 16)
+
+	n.mu.Lock()
+	if n.subs[folder] == nil {
+		n.subs[folder] = make(map[chan MailboxEvent]bool)
+	}
+	n.subs[folder][ch] = true
+	n.mu.Unlock()
+
+	unsubscribe := func() {
+		n.mu.Lock()
+		delete(n.subs[folder], ch)
+		if len(n.subs[folder]) == 0 {
+			delete(n.subs, folder)
+		}
+		n.mu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+// Publish fans ev out to every current subscriber of folder. A subscriber
+// that isn't keeping up is skipped rather than blocking the publisher.
+func (n *Notifier) Publish(folder string, ev MailboxEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.subs[folder] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package proto is the IMAP wire-level layer: reading client command lines
+// and literals, tokenizing them, and writing tagged/untagged responses
+// (including literal-bearing FETCH responses). It has no notion of
+// sessions or storage; imap/session and imap/handlers build on top of it.
+package proto
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// ReadTimeout bounds how long a connection may sit idle between commands
+// (or continuation lines) before it's dropped.
+const ReadTimeout = 30 * time.Minute
+
+// ReadLine performs a single blocking read of one client line. It assumes
+// one conn.Read call returns one full line, which holds for the short
+// command and continuation lines (SASL responses, IDLE's "DONE") this
+// server exchanges with clients.
+func ReadLine(conn net.Conn) (string, error) {
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(ReadTimeout))
+	n, err := conn.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf[:n])), nil
+}
+
+// ReadExactly blocks until n bytes have been read from conn, which is
+// needed because a single conn.Read call may return less than the full
+// literal (e.g. when the client's message spans multiple TCP segments).
+func ReadExactly(conn net.Conn, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read := 0
+	for read < n {
+		m, err := conn.Read(buf[read:])
+		if err != nil {
+			return nil, err
+		}
+		read += m
+	}
+	return buf, nil
+}
+
+// TokenizeIMAPLine splits a raw command line into tokens, treating a
+// double-quoted string or a parenthesized group as a single token so that
+// embedded spaces (flag lists, date-times) aren't torn apart the way
+// strings.Fields would tear them apart.
+func TokenizeIMAPLine(line string) []string {
+	var tokens []string
+	runes := []rune(line)
+	i := 0
+	for i < len(runes) {
+		for i < len(runes) && runes[i] == ' ' {
+			i++
+		}
+		if i >= len(runes) {
+			break
+		}
+		switch runes[i] {
+		case '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			tokens = append(tokens, string(runes[i+1:j]))
+			i = j + 1
+		case '(':
+			depth := 1
+			j := i + 1
+			for j < len(runes) && depth > 0 {
+				if runes[j] == '(' {
+					depth++
+				} else if runes[j] == ')' {
+					depth--
+				}
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			j := i
+			for j < len(runes) && runes[j] != ' ' {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens
+}
+
+// Command is one parsed client request line: its tag, upper-cased command
+// name, and the full whitespace-tokenized line (tokens[0]==tag,
+// tokens[1]==the command as written).
+type Command struct {
+	Tag   string
+	Name  string
+	Parts []string
+	Line  string
+}
+
+// ReadCommand reads and tokenizes one command line. ok is false for a blank
+// line (the caller should just read again) or a line too short to carry a
+// tag and command.
+func ReadCommand(conn net.Conn) (cmd Command, ok bool, err error) {
+	conn.SetReadDeadline(time.Now().Add(ReadTimeout))
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Command{}, false, err
+	}
+	line := strings.TrimSpace(string(buf[:n]))
+	if line == "" {
+		return Command{}, false, nil
+	}
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		return Command{Line: line}, false, nil
+	}
+	return Command{
+		Tag:   parts[0],
+		Name:  strings.ToUpper(parts[1]),
+		Parts: parts,
+		Line:  line,
+	}, true, nil
+}
+
+// Writer renders tagged/untagged IMAP responses onto a connection,
+// including literal-bearing FETCH responses.
+type Writer struct {
+	conn net.Conn
+}
+
+func NewWriter(conn net.Conn) *Writer { return &Writer{conn: conn} }
+
+// Send writes one response line, appending the CRLF every IMAP response
+// line requires.
+func (w *Writer) Send(response string) {
+	fmt.Printf("Server: %s\n", response)
+	w.conn.Write([]byte(response + "\r\n"))
+}
+
+// Sendf is Send with fmt.Sprintf formatting.
+func (w *Writer) Sendf(format string, args ...interface{}) {
+	w.Send(fmt.Sprintf(format, args...))
+}
+
+// SendFetch writes one untagged FETCH response, embedding any literal
+// fields (body sections) inline as "{n}\r\n<n bytes>" the way real IMAP
+// literal syntax requires, rather than on their own line.
+func (w *Writer) SendFetch(seq int, fields []storage.FetchField) {
+	w.conn.Write([]byte(fmt.Sprintf("* %d FETCH (", seq)))
+	for i, f := range fields {
+		if i > 0 {
+			w.conn.Write([]byte(" "))
+		}
+		if f.Literal {
+			w.conn.Write([]byte(fmt.Sprintf("%s {%d}\r\n", f.Label, len(f.Data))))
+			w.conn.Write(f.Data)
+		} else {
+			w.conn.Write([]byte(f.Label))
+		}
+	}
+	w.conn.Write([]byte(")\r\n"))
+}
+
+// ParseLiteralSpec parses a trailing "{octets}" or "{octets+}" token (the
+// non-synchronizing literal marker from RFC 7888) into its byte count and
+// whether the client expects a "+ " continuation before sending it.
+func ParseLiteralSpec(token string) (octets int, nonSync bool, ok bool) {
+	if !strings.HasPrefix(token, "{") || !strings.HasSuffix(token, "}") {
+		return 0, false, false
+	}
+	spec := strings.Trim(token, "{}")
+	nonSync = strings.HasSuffix(spec, "+")
+	spec = strings.TrimSuffix(spec, "+")
+	n, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, false, false
+	}
+	return n, nonSync, true
+}
@@ -0,0 +1,92 @@
+// Package session models one client connection's state across the three
+// connection states RFC 3501 3 defines: Not Authenticated, Authenticated
+// and Selected. Handlers consult it to decide whether a command is
+// currently permitted instead of re-deriving that from ad-hoc bool checks.
+package session
+
+import "net"
+
+// State is one of the three RFC 3501 3 connection states. There is a
+// fourth (Logout) but it has no commands of its own to gate, so it isn't
+// modeled separately here.
+type State int
+
+const (
+	NotAuthenticated State = iota
+	Authenticated
+	Selected
+)
+
+// Client is one connection's session state: which of the three RFC 3501
+// states it's in, its TLS/identity, and (once Selected) which mailbox.
+type Client struct {
+	Conn     net.Conn
+	Secure   bool
+	Username string
+
+	state            State
+	selectedName     string
+	selectedReadOnly bool
+}
+
+// New starts a session in the Not Authenticated state, as every connection
+// does regardless of how it reached the server (RFC 3501 3).
+func New(conn net.Conn, secure bool) *Client {
+	return &Client{Conn: conn, Secure: secure, state: NotAuthenticated}
+}
+
+func (c *Client) State() State { return c.state }
+
+// Authenticate transitions Not Authenticated -> Authenticated once LOGIN or
+// AUTHENTICATE succeeds.
+func (c *Client) Authenticate(username string) {
+	c.Username = username
+	c.state = Authenticated
+}
+
+// IsAuthenticated reports whether the session is Authenticated or Selected
+// (i.e. has passed LOGIN/AUTHENTICATE), the check most command handlers
+// need regardless of which mailbox, if any, is selected.
+func (c *Client) IsAuthenticated() bool {
+	return c.state == Authenticated || c.state == Selected
+}
+
+// Select transitions Authenticated/Selected -> Selected, per RFC 3501 3.2:
+// a second SELECT/EXAMINE from Selected just changes the currently
+// selected mailbox rather than requiring a trip back through Authenticated.
+func (c *Client) Select(mailbox string, readOnly bool) {
+	c.state = Selected
+	c.selectedName = mailbox
+	c.selectedReadOnly = readOnly
+}
+
+// Deselect transitions Selected -> Authenticated: after a failed
+// SELECT/EXAMINE (RFC 3501 6.3.1), or when the selected mailbox is deleted.
+func (c *Client) Deselect() {
+	c.state = Authenticated
+	c.selectedName = ""
+	c.selectedReadOnly = false
+}
+
+// SelectedMailbox returns the currently selected mailbox name, or "" when
+// the session isn't in the Selected state.
+func (c *Client) SelectedMailbox() string {
+	if c.state != Selected {
+		return ""
+	}
+	return c.selectedName
+}
+
+// ReadOnly reports whether the current mailbox was opened with EXAMINE
+// rather than SELECT (RFC 3501 6.3.2).
+func (c *Client) ReadOnly() bool {
+	return c.state == Selected && c.selectedReadOnly
+}
+
+// Logout resets the session to Not Authenticated and clears the selected
+// mailbox, since Logout isn't modeled as a State of its own. There's no
+// further command dispatch after LOGOUT; Server.Serve closes the connection.
+func (c *Client) Logout() {
+	c.state = NotAuthenticated
+	c.selectedName = ""
+}
@@ -0,0 +1,392 @@
+package storage
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchCriterion is a node in the AST produced by parsing an IMAP SEARCH
+// key per RFC 3501 6.4.4. It is backend-agnostic: Match is the only
+// operation a Backend is required to support. A backend that stores
+// messages in a queryable form (storage/sqlite) may still translate common
+// criteria to a native query as a private optimization, but that's an
+// implementation detail sqlite keeps to itself rather than exposing here.
+type SearchCriterion interface {
+	Match(msg *Message) bool
+}
+
+type AndCriterion struct{ Criteria []SearchCriterion }
+
+type OrCriterion struct{ Left, Right SearchCriterion }
+
+type NotCriterion struct{ Criterion SearchCriterion }
+
+// FlagCriterion matches system flags (SEEN, ANSWERED, ...) as well as the
+// pseudo-flags ALL, NEW, OLD and RECENT, which aren't stored flags but are
+// derived from the \Seen/\Recent state per RFC 3501.
+type FlagCriterion struct {
+	Flag string // upper-cased, e.g. "SEEN", "ANSWERED", "ALL", "NEW", "OLD", "RECENT"
+	Set  bool   // false for the UN* variants
+}
+
+type KeywordCriterion struct {
+	Keyword string
+	Set     bool
+}
+
+// HeaderCriterion covers SUBJECT/FROM/TO/CC/BCC and the generic HEADER
+// <field> <value> form. Field "" never matches; TEXT parses to
+// BodyCriterion instead.
+type HeaderCriterion struct {
+	Field string
+	Value string
+}
+
+// BodyCriterion implements BODY (message body only) and TEXT (headers + body).
+type BodyCriterion struct {
+	IncludeHeaders bool
+	Value          string
+}
+
+type SizeCriterion struct {
+	Larger bool // false means SMALLER
+	Size   int64
+}
+
+// DateCriterion implements BEFORE/ON/SINCE and their SENT* variants.
+type DateCriterion struct {
+	Sent bool
+	Op   string // "BEFORE", "ON", "SINCE"
+	Date time.Time
+}
+
+type UIDSetCriterion struct {
+	Set string // raw sequence-set text, e.g. "1:4,7"
+}
+
+// SeqSetCriterion matches a bare sequence-set search key (e.g. "SEARCH
+// 2:4"), which per RFC 3501 6.4.4 names message sequence numbers, not UIDs
+// — unlike "UID <set>", which parses as UIDSetCriterion instead.
+type SeqSetCriterion struct {
+	Set string // raw sequence-set text, e.g. "1:4,7"
+}
+
+func (c *AndCriterion) Match(msg *Message) bool {
+	for _, crit := range c.Criteria {
+		if !crit.Match(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *OrCriterion) Match(msg *Message) bool {
+	return c.Left.Match(msg) || c.Right.Match(msg)
+}
+
+func (c *NotCriterion) Match(msg *Message) bool {
+	return !c.Criterion.Match(msg)
+}
+
+func hasFlag(msg *Message, flag string) bool {
+	for _, f := range msg.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *FlagCriterion) Match(msg *Message) bool {
+	switch c.Flag {
+	case "ALL":
+		return true
+	case "NEW":
+		return hasFlag(msg, "\\Seen") == !c.Set
+	case "OLD":
+		// This server has no per-session \Recent tracking, so every stored
+		// message is treated as old.
+		return true
+	case "RECENT":
+		return false
+	}
+	return hasFlag(msg, "\\"+systemFlagTag(c.Flag)) == c.Set
+}
+
+// systemFlagTag maps an upper-cased criterion name (SEEN, DELETED, ...) to
+// the capitalized form used in stored flags (Seen, Deleted, ...).
+func systemFlagTag(flag string) string {
+	lower := strings.ToLower(flag)
+	if lower == "" {
+		return ""
+	}
+	return strings.ToUpper(lower[:1]) + lower[1:]
+}
+
+func (c *KeywordCriterion) Match(msg *Message) bool {
+	return hasFlag(msg, c.Keyword) == c.Set
+}
+
+func (c *HeaderCriterion) Match(msg *Message) bool {
+	header := headerOf(msg)
+	switch c.Field {
+	case "SUBJECT":
+		return strings.Contains(strings.ToLower(msg.Subject), strings.ToLower(c.Value))
+	case "FROM":
+		return strings.Contains(strings.ToLower(msg.Sender), strings.ToLower(c.Value))
+	case "TO":
+		return strings.Contains(strings.ToLower(msg.Recipient), strings.ToLower(c.Value))
+	case "":
+		return false
+	}
+	return strings.Contains(strings.ToLower(header.Get(c.Field)), strings.ToLower(c.Value))
+}
+
+func (c *BodyCriterion) Match(msg *Message) bool {
+	needle := strings.ToLower(c.Value)
+	haystack := msg.RawMessage
+	if !c.IncludeHeaders {
+		if idx := strings.Index(haystack, "\r\n\r\n"); idx != -1 {
+			haystack = haystack[idx+4:]
+		}
+	}
+	return strings.Contains(strings.ToLower(haystack), needle)
+}
+
+func (c *SizeCriterion) Match(msg *Message) bool {
+	if c.Larger {
+		return len(msg.RawMessage) > int(c.Size)
+	}
+	return len(msg.RawMessage) < int(c.Size)
+}
+
+func (c *DateCriterion) Match(msg *Message) bool {
+	sent, _ := time.Parse(time.RFC1123Z, msg.DateSent)
+	d := sent.Format("2006-01-02")
+	day := c.Date.Format("2006-01-02")
+	switch c.Op {
+	case "BEFORE":
+		return d < day
+	case "ON":
+		return d == day
+	case "SINCE":
+		return d >= day
+	}
+	return false
+}
+
+func (c *UIDSetCriterion) Match(msg *Message) bool {
+	return SequenceSetContains(c.Set, msg.UID)
+}
+
+func (c *SeqSetCriterion) Match(msg *Message) bool {
+	return SequenceSetContains(c.Set, msg.Seq)
+}
+
+// headerOf lazily parses msg.RawMessage's headers for criteria (CC, BCC,
+// generic HEADER) that aren't already denormalized onto Message.
+func headerOf(msg *Message) mail.Header {
+	m, err := mail.ReadMessage(strings.NewReader(msg.RawMessage))
+	if err != nil {
+		return mail.Header{}
+	}
+	return m.Header
+}
+
+// SequenceSetContains reports whether id falls within the sequence-set
+// (or UID-set) text set, e.g. "1:4,7,9:*".
+func SequenceSetContains(set string, id int) bool {
+	for _, part := range strings.Split(set, ",") {
+		if strings.Contains(part, ":") {
+			bounds := strings.SplitN(part, ":", 2)
+			lo, err1 := strconv.Atoi(bounds[0])
+			if bounds[1] == "*" {
+				if err1 == nil && id >= lo {
+					return true
+				}
+				continue
+			}
+			hi, err2 := strconv.Atoi(bounds[1])
+			if err1 == nil && err2 == nil && id >= lo && id <= hi {
+				return true
+			}
+		} else if n, err := strconv.Atoi(part); err == nil && n == id {
+			return true
+		}
+	}
+	return false
+}
+
+// searchParser is a recursive-descent parser over the whitespace-tokenized
+// SEARCH key, following the grammar in RFC 3501 6.4.4.
+type searchParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *searchParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *searchParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// parseSearchKeys parses zero or more search keys (implicit AND) until the
+// token stream is exhausted or a closing ")" is reached.
+func (p *searchParser) parseSearchKeys(stopAtParen bool) (SearchCriterion, error) {
+	var criteria []SearchCriterion
+	for p.pos < len(p.tokens) {
+		if stopAtParen && p.peek() == ")" {
+			break
+		}
+		crit, err := p.parseSearchKey()
+		if err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, crit)
+	}
+	if len(criteria) == 1 {
+		return criteria[0], nil
+	}
+	return &AndCriterion{Criteria: criteria}, nil
+}
+
+func (p *searchParser) parseSearchKey() (SearchCriterion, error) {
+	tok := p.next()
+	upper := strings.ToUpper(tok)
+
+	switch upper {
+	case "":
+		return nil, fmt.Errorf("unexpected end of search key")
+	case "(":
+		inner, err := p.parseSearchKeys(true)
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("expected ) to close search key group")
+		}
+		return inner, nil
+	case "NOT":
+		inner, err := p.parseSearchKey()
+		if err != nil {
+			return nil, err
+		}
+		return &NotCriterion{Criterion: inner}, nil
+	case "OR":
+		left, err := p.parseSearchKey()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.parseSearchKey()
+		if err != nil {
+			return nil, err
+		}
+		return &OrCriterion{Left: left, Right: right}, nil
+	case "ALL", "NEW", "OLD", "RECENT":
+		return &FlagCriterion{Flag: upper, Set: true}, nil
+	case "SEEN":
+		return &FlagCriterion{Flag: "SEEN", Set: true}, nil
+	case "UNSEEN":
+		return &FlagCriterion{Flag: "SEEN", Set: false}, nil
+	case "ANSWERED":
+		return &FlagCriterion{Flag: "ANSWERED", Set: true}, nil
+	case "UNANSWERED":
+		return &FlagCriterion{Flag: "ANSWERED", Set: false}, nil
+	case "FLAGGED":
+		return &FlagCriterion{Flag: "FLAGGED", Set: true}, nil
+	case "UNFLAGGED":
+		return &FlagCriterion{Flag: "FLAGGED", Set: false}, nil
+	case "DELETED":
+		return &FlagCriterion{Flag: "DELETED", Set: true}, nil
+	case "UNDELETED":
+		return &FlagCriterion{Flag: "DELETED", Set: false}, nil
+	case "DRAFT":
+		return &FlagCriterion{Flag: "DRAFT", Set: true}, nil
+	case "UNDRAFT":
+		return &FlagCriterion{Flag: "DRAFT", Set: false}, nil
+	case "KEYWORD":
+		return &KeywordCriterion{Keyword: p.next(), Set: true}, nil
+	case "UNKEYWORD":
+		return &KeywordCriterion{Keyword: p.next(), Set: false}, nil
+	case "SUBJECT", "FROM", "TO", "CC", "BCC":
+		return &HeaderCriterion{Field: upper, Value: p.next()}, nil
+	case "HEADER":
+		field := p.next()
+		return &HeaderCriterion{Field: field, Value: p.next()}, nil
+	case "BODY":
+		return &BodyCriterion{IncludeHeaders: false, Value: p.next()}, nil
+	case "TEXT":
+		return &BodyCriterion{IncludeHeaders: true, Value: p.next()}, nil
+	case "LARGER":
+		n, err := strconv.ParseInt(p.next(), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LARGER size: %w", err)
+		}
+		return &SizeCriterion{Larger: true, Size: n}, nil
+	case "SMALLER":
+		n, err := strconv.ParseInt(p.next(), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMALLER size: %w", err)
+		}
+		return &SizeCriterion{Larger: false, Size: n}, nil
+	case "BEFORE", "ON", "SINCE":
+		d, err := time.Parse("02-Jan-2006", p.next())
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %w", err)
+		}
+		return &DateCriterion{Sent: false, Op: upper, Date: d}, nil
+	case "SENTBEFORE", "SENTON", "SENTSINCE":
+		d, err := time.Parse("02-Jan-2006", p.next())
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %w", err)
+		}
+		return &DateCriterion{Sent: true, Op: strings.TrimPrefix(upper, "SENT"), Date: d}, nil
+	case "UID":
+		return &UIDSetCriterion{Set: p.next()}, nil
+	}
+
+	// Bare sequence-set, e.g. "1:4,7" — matches sequence numbers, not UIDs
+	// (RFC 3501 6.4.4); "UID <set>" above is the UID-matching form.
+	if isSequenceSet(tok) {
+		return &SeqSetCriterion{Set: tok}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported search key: %s", tok)
+}
+
+func isSequenceSet(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r != ',' && r != ':' && r != '*' && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSearchCriteria parses the tokens following SEARCH/UID SEARCH (the
+// command and tag already stripped), handling an optional leading
+// "CHARSET <name>" per RFC 3501 6.4.4.
+func ParseSearchCriteria(tokens []string) (SearchCriterion, error) {
+	if len(tokens) >= 2 && strings.EqualFold(tokens[0], "CHARSET") {
+		charset := strings.ToUpper(tokens[1])
+		if charset != "US-ASCII" && charset != "UTF-8" {
+			return nil, fmt.Errorf("unsupported charset: %s", tokens[1])
+		}
+		tokens = tokens[2:]
+	}
+	p := &searchParser{tokens: tokens}
+	return p.parseSearchKeys(false)
+}
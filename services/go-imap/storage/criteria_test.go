@@ -0,0 +1,91 @@
+package storage
+
+import "testing"
+
+func TestParseSearchCriteriaBareSequenceSetMatchesSeq(t *testing.T) {
+	crit, err := ParseSearchCriteria([]string{"2"})
+	if err != nil {
+		t.Fatalf("ParseSearchCriteria: %v", err)
+	}
+	if _, ok := crit.(*SeqSetCriterion); !ok {
+		t.Fatalf("bare sequence-set parsed as %T, want *SeqSetCriterion", crit)
+	}
+
+	// Seq 2, UID 100: a bare sequence-set must match by sequence number, not
+	// UID, per RFC 3501 6.4.4.
+	msg := &Message{Seq: 2, UID: 100}
+	if !crit.Match(msg) {
+		t.Fatalf("want bare set %q to match seq %d", "2", msg.Seq)
+	}
+	if crit.Match(&Message{Seq: 5, UID: 2}) {
+		t.Fatalf("bare set %q matched by UID instead of seq", "2")
+	}
+}
+
+func TestParseSearchCriteriaUIDKeywordMatchesUID(t *testing.T) {
+	crit, err := ParseSearchCriteria([]string{"UID", "100"})
+	if err != nil {
+		t.Fatalf("ParseSearchCriteria: %v", err)
+	}
+	if _, ok := crit.(*UIDSetCriterion); !ok {
+		t.Fatalf("UID <set> parsed as %T, want *UIDSetCriterion", crit)
+	}
+	if !crit.Match(&Message{Seq: 2, UID: 100}) {
+		t.Fatalf("want UID set %q to match UID 100", "100")
+	}
+	if crit.Match(&Message{Seq: 100, UID: 2}) {
+		t.Fatalf("UID set %q matched by seq instead of UID", "100")
+	}
+}
+
+func TestParseSearchCriteriaAndOrNot(t *testing.T) {
+	// RFC 3501 6.4.4's OR takes exactly two atomic search-keys, so this
+	// parses as And(Or(Seen, Not(Answered)), From(alice)) — "FROM
+	// alice@example.com" is its own top-level key, implicitly ANDed with
+	// the OR, not folded into the OR's second operand.
+	crit, err := ParseSearchCriteria([]string{"OR", "SEEN", "NOT", "ANSWERED", "FROM", "alice@example.com"})
+	if err != nil {
+		t.Fatalf("ParseSearchCriteria: %v", err)
+	}
+
+	seenFromAlice := &Message{Flags: []string{"\\Seen"}, Sender: "alice@example.com"}
+	if !crit.Match(seenFromAlice) {
+		t.Fatalf("want match: OR's left branch (SEEN) holds and FROM alice holds")
+	}
+
+	unseenUnansweredFromAlice := &Message{Sender: "alice@example.com"}
+	if !crit.Match(unseenUnansweredFromAlice) {
+		t.Fatalf("want match: OR's right branch (NOT ANSWERED) holds and FROM alice holds")
+	}
+
+	seenFromBob := &Message{Flags: []string{"\\Seen"}, Sender: "bob@example.com"}
+	if crit.Match(seenFromBob) {
+		t.Fatalf("want no match: OR is satisfied but the ANDed FROM alice is not")
+	}
+
+	unseenAnsweredFromAlice := &Message{Flags: []string{"\\Answered"}, Sender: "alice@example.com"}
+	if crit.Match(unseenAnsweredFromAlice) {
+		t.Fatalf("want no match: FROM alice holds but neither OR branch does")
+	}
+}
+
+func TestSequenceSetContainsOpenEndedStar(t *testing.T) {
+	cases := []struct {
+		set  string
+		id   int
+		want bool
+	}{
+		{"1:*", 1, true},
+		{"1:*", 50, true},
+		{"5:*", 4, false},
+		{"5:*", 5, true},
+		{"*", 7, false}, // bare "*" alone isn't a range; no id equals the literal token
+		{"1:4,7", 7, true},
+		{"1:4,7", 5, false},
+	}
+	for _, c := range cases {
+		if got := SequenceSetContains(c.set, c.id); got != c.want {
+			t.Errorf("SequenceSetContains(%q, %d) = %v, want %v", c.set, c.id, got, c.want)
+		}
+	}
+}
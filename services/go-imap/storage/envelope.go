@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Envelope renders the ENVELOPE fetch item (RFC 3501 7.4.2) for a message:
+// a parenthesized list of its Date, Subject, From/Sender/Reply-To/To/Cc/Bcc
+// address lists, In-Reply-To and Message-Id, parsed from raw's headers with
+// net/mail the same way storage/thread.go parses References.
+func Envelope(raw []byte) string {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return "(NIL NIL NIL NIL NIL NIL NIL NIL NIL NIL)"
+	}
+	h := m.Header
+
+	from := envAddressList(h, "From")
+	sender := envAddressList(h, "Sender")
+	if sender == "NIL" {
+		sender = from
+	}
+	replyTo := envAddressList(h, "Reply-To")
+	if replyTo == "NIL" {
+		replyTo = from
+	}
+
+	return fmt.Sprintf("(%s %s %s %s %s %s %s %s %s %s)",
+		imapQuoteOrNil(h.Get("Date")),
+		imapQuoteOrNil(h.Get("Subject")),
+		from,
+		sender,
+		replyTo,
+		envAddressList(h, "To"),
+		envAddressList(h, "Cc"),
+		envAddressList(h, "Bcc"),
+		imapQuoteOrNil(h.Get("In-Reply-To")),
+		imapQuoteOrNil(h.Get("Message-Id")),
+	)
+}
+
+// envAddressList renders one address-list header as an ENVELOPE address
+// structure list: "(" "(" name adl mailbox host ")" ... ")", or "NIL" when
+// the header is absent or unparseable.
+func envAddressList(h mail.Header, field string) string {
+	if h.Get(field) == "" {
+		return "NIL"
+	}
+	addrs, err := h.AddressList(field)
+	if err != nil || len(addrs) == 0 {
+		return "NIL"
+	}
+	var sb strings.Builder
+	sb.WriteString("(")
+	for i, a := range addrs {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		mailbox, host := a.Address, ""
+		if idx := strings.LastIndex(a.Address, "@"); idx >= 0 {
+			mailbox, host = a.Address[:idx], a.Address[idx+1:]
+		}
+		sb.WriteString(fmt.Sprintf("(%s NIL %s %s)", imapQuoteOrNil(a.Name), imapQuote(mailbox), imapQuote(host)))
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// internalDateFetchField formats msg.InternalDate (stored as RFC1123Z) into
+// the quoted "dd-Mon-yyyy hh:mm:ss +zzzz" string INTERNALDATE uses (RFC 3501
+// 2.3.3), falling back to quoting the stored string as-is if it doesn't
+// parse as RFC1123Z.
+func internalDateFetchField(internalDate string) string {
+	t, err := time.Parse(time.RFC1123Z, internalDate)
+	if err != nil {
+		return imapQuoteOrNil(internalDate)
+	}
+	return imapQuote(t.Format("02-Jan-2006 15:04:05 -0700"))
+}
@@ -0,0 +1,12 @@
+package storage
+
+import "errors"
+
+// Sentinel errors a Backend returns for conditions imap/handlers needs to
+// distinguish and translate into specific IMAP response codes (e.g.
+// [TRYCREATE]), rather than a single opaque failure.
+var (
+	ErrMailboxNotFound  = errors.New("mailbox does not exist")
+	ErrMailboxExists    = errors.New("mailbox already exists")
+	ErrInboxUndeletable = errors.New("INBOX cannot be deleted")
+)
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TokenizeFetchItems splits a FETCH items string into its individual
+// fetch-att tokens, treating (), [] and <> as nested and never splitting
+// inside them — so "BODY.PEEK[HEADER.FIELDS (FROM TO)]<0.100>" stays one
+// token instead of breaking on its internal spaces.
+func TokenizeFetchItems(items string) []string {
+	var tokens []string
+	runes := []rune(items)
+	i := 0
+	for i < len(runes) {
+		for i < len(runes) && runes[i] == ' ' {
+			i++
+		}
+		if i >= len(runes) {
+			break
+		}
+		start := i
+		depth := 0
+		for i < len(runes) {
+			if runes[i] == ' ' && depth == 0 {
+				break
+			}
+			switch runes[i] {
+			case '(', '[', '<':
+				depth++
+			case ')', ']', '>':
+				depth--
+			}
+			i++
+		}
+		tokens = append(tokens, string(runes[start:i]))
+	}
+	return tokens
+}
+
+// PartialSpec is a FETCH <start.length> byte-range modifier (RFC 3501
+// 6.4.5). Length < 0 means "to the end of the data".
+type PartialSpec struct {
+	Start, Length int
+}
+
+// SplitPartial strips a trailing <start.length> from a fetch-att token (if
+// present) and returns the remainder along with the parsed range.
+func SplitPartial(token string) (rest string, partial *PartialSpec) {
+	open := strings.LastIndex(token, "<")
+	if open == -1 || !strings.HasSuffix(token, ">") {
+		return token, nil
+	}
+	spec := token[open+1 : len(token)-1]
+	dot := strings.Index(spec, ".")
+	if dot == -1 {
+		return token, nil
+	}
+	start, err1 := strconv.Atoi(spec[:dot])
+	length, err2 := strconv.Atoi(spec[dot+1:])
+	if err1 != nil || err2 != nil {
+		return token, nil
+	}
+	return token[:open], &PartialSpec{Start: start, Length: length}
+}
+
+// Apply slices data per the <start.length> range, clamping to data's
+// bounds the way real IMAP servers do rather than erroring.
+func (p *PartialSpec) Apply(data []byte) []byte {
+	if p.Start >= len(data) {
+		return nil
+	}
+	end := len(data)
+	if p.Length >= 0 && p.Start+p.Length < end {
+		end = p.Start + p.Length
+	}
+	return data[p.Start:end]
+}
+
+// SectionData resolves a FETCH body section spec (the contents of
+// BODY[...] / BODY.PEEK[...]) against a parsed MIME tree and the message's
+// raw bytes. spec examples: "" (whole message), "TEXT", "HEADER",
+// "HEADER.FIELDS (FROM TO)", "1", "1.2", "1.TEXT", "1.MIME".
+func SectionData(root *MIMEPart, raw []byte, spec string) ([]byte, bool) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return raw[root.HeaderOffset : root.BodyOffset+root.BodyLength], true
+	}
+
+	numPath, keyword := splitSectionSpec(spec)
+
+	part := root
+	if numPath != "" {
+		segs := strings.Split(numPath, ".")
+		start := 0
+		// A non-multipart message has no numbered children of its own;
+		// section "1" addresses the message body itself.
+		if root.Type != "MULTIPART" {
+			if segs[0] != "1" {
+				return nil, false
+			}
+			start = 1
+		}
+		for _, seg := range segs[start:] {
+			n, err := strconv.Atoi(seg)
+			if err != nil || part == nil || n < 1 || n > len(part.Children) {
+				return nil, false
+			}
+			part = part.Children[n-1]
+		}
+	}
+
+	keyword = strings.ToUpper(keyword)
+	switch {
+	case keyword == "":
+		if numPath != "" && root.Type != "MULTIPART" {
+			return raw[part.BodyOffset : part.BodyOffset+part.BodyLength], true
+		}
+		return raw[part.HeaderOffset : part.BodyOffset+part.BodyLength], true
+	case keyword == "TEXT":
+		if part.Type == "MULTIPART" {
+			return nil, false
+		}
+		return raw[part.BodyOffset : part.BodyOffset+part.BodyLength], true
+	case keyword == "MIME" || keyword == "HEADER":
+		return raw[part.HeaderOffset:part.BodyOffset], true
+	case strings.HasPrefix(keyword, "HEADER.FIELDS"):
+		return filterHeaderFields(raw[part.HeaderOffset:part.BodyOffset], keyword), true
+	}
+	return nil, false
+}
+
+// splitSectionSpec separates a section spec's leading dotted numeric part
+// path (e.g. "1.2" of "1.2.TEXT") from its trailing keyword, if any.
+func splitSectionSpec(spec string) (numPath, keyword string) {
+	segs := strings.Split(spec, ".")
+	i := 0
+	for i < len(segs) {
+		if _, err := strconv.Atoi(segs[i]); err != nil {
+			break
+		}
+		i++
+	}
+	return strings.Join(segs[:i], "."), strings.Join(segs[i:], ".")
+}
+
+// filterHeaderFields implements HEADER.FIELDS/HEADER.FIELDS.NOT (RFC 3501
+// 6.4.5): headerBlock restricted to (or excluding) the named fields,
+// matched case-insensitively, terminated by the mandatory trailing blank
+// line.
+func filterHeaderFields(headerBlock []byte, keyword string) []byte {
+	negate := strings.HasPrefix(keyword, "HEADER.FIELDS.NOT")
+	open := strings.Index(keyword, "(")
+	closeIdx := strings.LastIndex(keyword, ")")
+	var names []string
+	if open != -1 && closeIdx != -1 && closeIdx > open {
+		for _, f := range strings.FieldsFunc(keyword[open+1:closeIdx], func(r rune) bool { return r == ' ' || r == ',' }) {
+			names = append(names, strings.ToUpper(strings.TrimSpace(f)))
+		}
+	}
+
+	wanted := func(fieldLine string) bool {
+		upper := strings.ToUpper(fieldLine)
+		for _, name := range names {
+			if strings.HasPrefix(upper, name+":") {
+				return !negate
+			}
+		}
+		return negate
+	}
+
+	// Group physical lines into logical header fields first: a line
+	// starting with whitespace is a folded continuation of the previous
+	// field (RFC 5322 2.2.3), not a field of its own, so it has to travel
+	// with its parent or wanted() would see no field name to match and a
+	// folded value would come out truncated to its first line.
+	var fields [][]string
+	for _, line := range strings.Split(strings.TrimRight(string(headerBlock), "\r\n"), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(fields) > 0 {
+			fields[len(fields)-1] = append(fields[len(fields)-1], line)
+			continue
+		}
+		fields = append(fields, []string{line})
+	}
+
+	var kept []string
+	for _, field := range fields {
+		if wanted(field[0]) {
+			kept = append(kept, strings.Join(field, "\r\n"))
+		}
+	}
+	return []byte(strings.Join(kept, "\r\n") + "\r\n\r\n")
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderFetchItems interprets itemsText (a FETCH fetch-att list, still
+// parenthesized) against msg and returns one FetchField per recognized
+// item, plus whether any of them requires marking the message \Seen.
+// Backends call this from their Fetch implementation so every backend
+// renders FETCH items identically; only how a backend looks up msg and
+// persists the resulting \Seen flag differs.
+func RenderFetchItems(msg *Message, itemsText string) (fields []FetchField, marksSeen bool) {
+	raw := []byte(msg.RawMessage)
+	if !strings.Contains(msg.RawMessage, "\r\n") {
+		raw = []byte(strings.ReplaceAll(msg.RawMessage, "\n", "\r\n"))
+	}
+	root := UnmarshalMIME(msg.MIMEStructure, raw)
+
+	for _, token := range TokenizeFetchItems(strings.Trim(itemsText, "()")) {
+		field, seen, ok := fetchOne(msg, raw, root, token)
+		if !ok {
+			continue
+		}
+		fields = append(fields, field)
+		marksSeen = marksSeen || seen
+	}
+	return fields, marksSeen
+}
+
+// fetchOne renders a single fetch-att token against one message. ok is
+// false for tokens this server doesn't recognize, which are silently
+// skipped the way unsupported STATUS items elsewhere in this server are.
+func fetchOne(msg *Message, raw []byte, root *MIMEPart, token string) (field FetchField, marksSeen, ok bool) {
+	upper := strings.ToUpper(token)
+
+	switch {
+	case upper == "FLAGS":
+		if len(msg.Flags) > 0 {
+			return FetchField{Label: fmt.Sprintf("FLAGS (%s)", strings.Join(msg.Flags, " "))}, false, true
+		}
+		return FetchField{Label: "FLAGS ()"}, false, true
+
+	case upper == "UID":
+		return FetchField{Label: fmt.Sprintf("UID %d", msg.UID)}, false, true
+
+	case upper == "RFC822.SIZE":
+		return FetchField{Label: fmt.Sprintf("RFC822.SIZE %d", len(raw))}, false, true
+
+	case upper == "ENVELOPE":
+		return FetchField{Label: "ENVELOPE " + Envelope(raw)}, false, true
+
+	case upper == "INTERNALDATE":
+		return FetchField{Label: "INTERNALDATE " + internalDateFetchField(msg.InternalDate)}, false, true
+
+	case upper == "BODYSTRUCTURE":
+		return FetchField{Label: "BODYSTRUCTURE " + BodyStructure(root, true)}, false, true
+
+	case upper == "BODY":
+		return FetchField{Label: "BODY " + BodyStructure(root, false)}, false, true
+
+	case upper == "RFC822":
+		return FetchField{Label: "RFC822", Literal: true, Data: raw}, true, true
+
+	case upper == "RFC822.HEADER":
+		data, _ := SectionData(root, raw, "HEADER")
+		return FetchField{Label: "RFC822.HEADER", Literal: true, Data: data}, false, true
+
+	case upper == "RFC822.TEXT":
+		data, _ := SectionData(root, raw, "TEXT")
+		return FetchField{Label: "RFC822.TEXT", Literal: true, Data: data}, true, true
+
+	case strings.HasPrefix(upper, "BODY[") || strings.HasPrefix(upper, "BODY.PEEK["):
+		return fetchBodySection(root, raw, token, upper)
+	}
+
+	return FetchField{}, false, false
+}
+
+// fetchBodySection handles BODY[section]/BODY.PEEK[section], each
+// optionally followed by a <start.length> partial-fetch range (RFC 3501
+// 6.4.5). BODY[] without .PEEK marks the message \Seen; BODY.PEEK[] never
+// does.
+func fetchBodySection(root *MIMEPart, raw []byte, token, upper string) (FetchField, bool, bool) {
+	peek := strings.HasPrefix(upper, "BODY.PEEK[")
+	prefix := "BODY["
+	if peek {
+		prefix = "BODY.PEEK["
+	}
+	if !strings.HasPrefix(upper, prefix) {
+		return FetchField{}, false, false
+	}
+
+	body, partial := SplitPartial(token)
+	if len(body) < len(prefix)+1 || !strings.HasSuffix(body, "]") {
+		return FetchField{}, false, false
+	}
+	spec := body[len(prefix) : len(body)-1]
+
+	data, found := SectionData(root, raw, spec)
+	if !found {
+		return FetchField{}, false, false
+	}
+
+	label := fmt.Sprintf("BODY[%s]", spec)
+	if partial != nil {
+		data = partial.Apply(data)
+		label = fmt.Sprintf("%s<%d>", label, partial.Start)
+	}
+
+	return FetchField{Label: label, Literal: true, Data: data}, !peek, true
+}
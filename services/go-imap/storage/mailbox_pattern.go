@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchMailboxPattern implements the LIST/LSUB wildcard rules from RFC
+// 3501 6.3.8: "%" matches any run of characters except the hierarchy
+// delimiter, "*" matches any run of characters including the delimiter.
+// It's shared by every backend's LIST/LSUB filtering so the wildcard
+// semantics stay identical regardless of how a backend stores mailboxes.
+func MatchMailboxPattern(pattern, name string) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			re.WriteString("[^/]*")
+		case '*':
+			re.WriteString(".*")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString("$")
+	matched, err := regexp.MatchString(re.String(), name)
+	return err == nil && matched
+}
@@ -0,0 +1,24 @@
+package storage
+
+import "testing"
+
+func TestMatchMailboxPattern(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"INBOX", "INBOX", true},
+		{"INBOX", "INBOX/Sent", false},
+		{"INBOX/%", "INBOX/Sent", true},
+		{"INBOX/%", "INBOX/Sent/2024", false}, // "%" stops at the delimiter
+		{"INBOX/*", "INBOX/Sent/2024", true},  // "*" crosses delimiters
+		{"*", "Anything/Nested/Deeply", true},
+		{"%", "INBOX", true},
+		{"%", "INBOX/Sent", false},
+	}
+	for _, c := range cases {
+		if got := MatchMailboxPattern(c.pattern, c.name); got != c.want {
+			t.Errorf("MatchMailboxPattern(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
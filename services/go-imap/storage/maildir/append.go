@@ -0,0 +1,71 @@
+package maildir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+var deliveryCounter int64
+
+// Append implements APPEND by delivering raw into mailbox's new/ directory
+// following the classic Maildir write sequence: write to tmp/, then link
+// (here, rename — single-host, no NFS) into new/ so a reader never observes
+// a partially written file. It holds Backend.mu for the whole delivery (not
+// just the UID lookup) so a concurrent scan can't observe the file in new/
+// or cur/ before .uidlist has been updated to account for it.
+func (b *Backend) Append(mailbox string, raw []byte, flags []string, internalDate time.Time) (uid int, uidValidity int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.mailboxExists(mailbox) {
+		return 0, 0, storage.ErrMailboxNotFound
+	}
+
+	name := uniqueName()
+	tmpPath := filepath.Join(b.mailboxDir(mailbox), "tmp", name)
+	if err := os.WriteFile(tmpPath, raw, 0o644); err != nil {
+		return 0, 0, err
+	}
+
+	dir := "new"
+	destName := name
+	if len(flags) > 0 {
+		// A message delivered with flags already set (as APPEND allows) is
+		// filed straight into cur/, matching how real MDAs handle messages
+		// that shouldn't show up as "new" (e.g. restored from another
+		// client with \Seen already set).
+		dir = "cur"
+		destName = name + ":2," + encodeFlags(flags)
+	}
+	destPath := filepath.Join(b.mailboxDir(mailbox), dir, destName)
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, 0, err
+	}
+	os.Chtimes(destPath, internalDate, internalDate)
+
+	entries, err := b.scanLocked(mailbox)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, e := range entries {
+		if e.base == name {
+			uidValidity, _, _ = b.loadUIDList(mailbox)
+			return e.uid, uidValidity, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("delivered message not found after scan")
+}
+
+// uniqueName follows the Maildir unique-name convention (RFC: time, PID,
+// delivery count, hostname) closely enough to avoid collisions on one host.
+func uniqueName() string {
+	count := atomic.AddInt64(&deliveryCounter, 1)
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%d.P%d_%d.%s", time.Now().UnixNano(), os.Getpid(), count, host)
+}
@@ -0,0 +1,38 @@
+package maildir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Authenticate checks username/password against root/.users, one
+// "username:bcrypt-hash" line per account. Unlike storage/sqlite there's no
+// bundled "adduser" CLI for this backend yet — a spool pointed at by
+// --backend maildir is expected to already have its own delivery/account
+// setup, so this file is meant to be populated by hand or by whatever
+// provisioning manages the spool.
+func (b *Backend) Authenticate(username, password string) bool {
+	data, err := os.ReadFile(b.usersPath())
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] != username {
+			continue
+		}
+		return bcrypt.CompareHashAndPassword([]byte(parts[1]), []byte(password)) == nil
+	}
+	return false
+}
+
+func (b *Backend) usersPath() string {
+	return filepath.Join(b.root, ".users")
+}
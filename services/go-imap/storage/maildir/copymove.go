@@ -0,0 +1,82 @@
+package maildir
+
+import (
+	"os"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Copy implements COPY/UID COPY by delivering a fresh copy of each matched
+// message into destMailbox via Append, carrying over its flags.
+func (b *Backend) Copy(srcMailbox, destMailbox, seqset string, useUID bool) (storage.CopyResult, error) {
+	_, result, err := b.copyInto(srcMailbox, destMailbox, seqset, useUID)
+	return result, err
+}
+
+// Move implements MOVE/UID MOVE: copies into destMailbox exactly like Copy,
+// then deletes the originals, reporting them as expunged in descending
+// sequence order.
+func (b *Backend) Move(srcMailbox, destMailbox, seqset string, useUID bool) (storage.CopyResult, []storage.ExpungeResult, error) {
+	entries, result, err := b.copyInto(srcMailbox, destMailbox, seqset, useUID)
+	if err != nil {
+		return storage.CopyResult{}, nil, err
+	}
+
+	var expunged []storage.ExpungeResult
+	for i := len(entries) - 1; i >= 0; i-- {
+		os.Remove(entries[i].path)
+		expunged = append(expunged, storage.ExpungeResult{Seq: entries[i].seq})
+	}
+	return result, expunged, nil
+}
+
+func (b *Backend) copyInto(srcMailbox, destMailbox, seqset string, useUID bool) ([]entry, storage.CopyResult, error) {
+	if !b.mailboxExists(destMailbox) {
+		return nil, storage.CopyResult{}, storage.ErrMailboxNotFound
+	}
+	entries, err := b.resolveSet(srcMailbox, seqset, useUID)
+	if err != nil {
+		return nil, storage.CopyResult{}, err
+	}
+
+	destValidity, _ := b.uidState(destMailbox)
+	result := storage.CopyResult{DestUIDValidity: destValidity}
+	for _, e := range entries {
+		raw, err := os.ReadFile(e.path)
+		if err != nil {
+			return nil, storage.CopyResult{}, err
+		}
+		info, _ := os.Stat(e.path)
+		internalDate := info.ModTime()
+		destUID, _, err := b.Append(destMailbox, raw, decodeFlags(e.flags), internalDate)
+		if err != nil {
+			return nil, storage.CopyResult{}, err
+		}
+		result.SrcUIDs = append(result.SrcUIDs, e.uid)
+		result.DestUIDs = append(result.DestUIDs, destUID)
+	}
+	return entries, result, nil
+}
+
+// Expunge implements EXPUNGE/UID EXPUNGE: deletes every \Deleted message
+// named by seqset, in descending sequence order.
+func (b *Backend) Expunge(mailbox, seqset string, useUID bool) ([]storage.ExpungeResult, error) {
+	entries, err := b.resolveSet(mailbox, seqset, useUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var toExpunge []entry
+	for _, e := range entries {
+		if hasFlag(e.flags, 'T') {
+			toExpunge = append(toExpunge, e)
+		}
+	}
+
+	var results []storage.ExpungeResult
+	for i := len(toExpunge) - 1; i >= 0; i-- {
+		os.Remove(toExpunge[i].path)
+		results = append(results, storage.ExpungeResult{Seq: toExpunge[i].seq})
+	}
+	return results, nil
+}
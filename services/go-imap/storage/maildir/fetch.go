@@ -0,0 +1,96 @@
+package maildir
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Fetch implements FETCH/UID FETCH against the on-disk spool, interpreting
+// itemsText with storage.RenderFetchItems exactly like storage/sqlite does.
+func (b *Backend) Fetch(mailbox, seqset, itemsText string, useUID bool) ([]storage.FetchResult, error) {
+	entries, err := b.resolveSet(mailbox, seqset, useUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []storage.FetchResult
+	for _, e := range entries {
+		msg, err := loadMessage(e)
+		if err != nil {
+			continue
+		}
+		fields, marksSeen := storage.RenderFetchItems(&msg, itemsText)
+		if useUID {
+			hasUID := false
+			for _, f := range fields {
+				if f.Label == "UID "+strconv.Itoa(e.uid) {
+					hasUID = true
+					break
+				}
+			}
+			if !hasUID {
+				fields = append([]storage.FetchField{{Label: "UID " + strconv.Itoa(e.uid)}}, fields...)
+			}
+		}
+		if marksSeen && !hasFlag(e.flags, 'S') {
+			b.applyFlags(mailbox, e, storage.StoreAdd, []string{"\\Seen"})
+		}
+		results = append(results, storage.FetchResult{Seq: e.seq, UID: e.uid, Fields: fields})
+	}
+	return results, nil
+}
+
+// Store implements STORE/UID STORE by renaming each matched file into
+// cur/ with an updated ":2,<flags>" suffix, which is how Maildir persists
+// flags (there being no separate metadata store).
+func (b *Backend) Store(mailbox, seqset string, useUID bool, mode storage.StoreMode, flags []string) ([]storage.StoreResult, error) {
+	entries, err := b.resolveSet(mailbox, seqset, useUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []storage.StoreResult
+	for _, e := range entries {
+		newEntry, err := b.applyFlags(mailbox, e, mode, flags)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, storage.StoreResult{Seq: e.seq, UID: e.uid, Flags: decodeFlags(newEntry.flags)})
+	}
+	return results, nil
+}
+
+// applyFlags computes e's new Maildir flag letters per mode and renames its
+// file into cur/ to match, since a message's delivery status (new vs. seen)
+// is itself tracked by which of new/ or cur/ it lives in.
+func (b *Backend) applyFlags(mailbox string, e entry, mode storage.StoreMode, flags []string) (entry, error) {
+	current := decodeFlags(e.flags)
+	var next []string
+	switch mode {
+	case storage.StoreSet:
+		next = flags
+	case storage.StoreAdd:
+		next = append(append([]string{}, current...), flags...)
+	case storage.StoreRemove:
+		removed := map[string]bool{}
+		for _, f := range flags {
+			removed[f] = true
+		}
+		for _, f := range current {
+			if !removed[f] {
+				next = append(next, f)
+			}
+		}
+	}
+
+	newName := e.base + ":2," + encodeFlags(next)
+	newPath := filepath.Join(b.mailboxDir(mailbox), "cur", newName)
+	if err := os.Rename(e.path, newPath); err != nil {
+		return entry{}, err
+	}
+	e.path, e.dir, e.flags = newPath, "cur", encodeFlags(next)
+	return e, nil
+}
@@ -0,0 +1,192 @@
+// Package maildir is a storage.Backend over an on-disk Maildir spool
+// (cur/new/tmp per mailbox), for pointing this server at mail delivered by
+// existing MDA tooling instead of the bundled SQLite database. It covers
+// the same Backend surface as storage/sqlite, but intentionally stays
+// minimal: no per-message MIME-structure cache (parsed on the fly from the
+// file on disk) and no password storage beyond a flat ".users" file, since
+// credential and delivery setup for a real mail spool is normally owned by
+// the MDA, not this server.
+package maildir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Backend is the Maildir-backed storage.Backend. Root contains one
+// subdirectory per mailbox, each a standard cur/new/tmp Maildir. mu guards
+// every read-modify-write of a mailbox's .uidlist (scan/uidState), since
+// one goroutine per connection (see main.go's accept loop) can otherwise
+// race to hand out the same UID twice — the Maildir equivalent of the
+// transaction storage/sqlite.allocateUID wraps its own UID allocation in.
+type Backend struct {
+	root string
+	mu   sync.Mutex
+}
+
+// Open prepares root for use, creating it (and an INBOX mailbox inside it)
+// if it doesn't exist yet.
+func Open(root string) (*Backend, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	b := &Backend{root: root}
+	if !b.mailboxExists("INBOX") {
+		if err := b.CreateMailbox("INBOX"); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (b *Backend) mailboxDir(name string) string {
+	return filepath.Join(b.root, name)
+}
+
+func (b *Backend) mailboxExists(name string) bool {
+	info, err := os.Stat(b.mailboxDir(name))
+	return err == nil && info.IsDir()
+}
+
+// mailboxNamePattern restricts mailbox names to what's safe to use as a
+// single path segment, since Maildir has no quoting convention for a name
+// containing "/" or "..".
+var mailboxNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+func validMailboxName(name string) bool {
+	return name != "" && mailboxNamePattern.MatchString(name) && name != "." && name != ".."
+}
+
+func (b *Backend) ListMailboxes() ([]storage.Mailbox, error) {
+	entries, err := os.ReadDir(b.root)
+	if err != nil {
+		return nil, err
+	}
+	subscribed := b.loadSubscriptions()
+
+	var mailboxes []storage.Mailbox
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		uidValidity, uidNext := b.uidState(e.Name())
+		mailboxes = append(mailboxes, storage.Mailbox{
+			Name:        e.Name(),
+			Delimiter:   "/",
+			UIDValidity: uidValidity,
+			UIDNext:     uidNext,
+			Subscribed:  subscribed[e.Name()],
+		})
+	}
+	return mailboxes, nil
+}
+
+func (b *Backend) CreateMailbox(name string) error {
+	if !validMailboxName(name) {
+		return fmt.Errorf("invalid mailbox name: %s", name)
+	}
+	if b.mailboxExists(name) {
+		return storage.ErrMailboxExists
+	}
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(b.mailboxDir(name), sub), 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backend) DeleteMailbox(name string) error {
+	if strings.EqualFold(name, "INBOX") {
+		return storage.ErrInboxUndeletable
+	}
+	if !b.mailboxExists(name) {
+		return storage.ErrMailboxNotFound
+	}
+	return os.RemoveAll(b.mailboxDir(name))
+}
+
+func (b *Backend) RenameMailbox(oldName, newName string) error {
+	if !b.mailboxExists(oldName) {
+		return storage.ErrMailboxNotFound
+	}
+	if !validMailboxName(newName) || b.mailboxExists(newName) {
+		return storage.ErrMailboxExists
+	}
+	return os.Rename(b.mailboxDir(oldName), b.mailboxDir(newName))
+}
+
+func (b *Backend) SetSubscribed(name string, subscribed bool) error {
+	if !b.mailboxExists(name) {
+		return storage.ErrMailboxNotFound
+	}
+	subs := b.loadSubscriptions()
+	if subscribed {
+		subs[name] = true
+	} else {
+		delete(subs, name)
+	}
+	return b.saveSubscriptions(subs)
+}
+
+func (b *Backend) loadSubscriptions() map[string]bool {
+	subs := map[string]bool{}
+	data, err := os.ReadFile(filepath.Join(b.root, ".subscriptions"))
+	if err != nil {
+		return subs
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			subs[line] = true
+		}
+	}
+	return subs
+}
+
+func (b *Backend) saveSubscriptions(subs map[string]bool) error {
+	var names []string
+	for name := range subs {
+		names = append(names, name)
+	}
+	return os.WriteFile(filepath.Join(b.root, ".subscriptions"), []byte(strings.Join(names, "\n")+"\n"), 0o644)
+}
+
+func (b *Backend) statusOf(name string) (storage.MailboxStatus, error) {
+	if !b.mailboxExists(name) {
+		return storage.MailboxStatus{}, storage.ErrMailboxNotFound
+	}
+	entries, err := b.scan(name)
+	if err != nil {
+		return storage.MailboxStatus{}, err
+	}
+	uidValidity, uidNext := b.uidState(name)
+	subs := b.loadSubscriptions()
+
+	st := storage.MailboxStatus{
+		Mailbox: storage.Mailbox{
+			Name: name, Delimiter: "/",
+			UIDValidity: uidValidity, UIDNext: uidNext,
+			Subscribed: subs[name],
+		},
+	}
+	st.Messages = len(entries)
+	for _, e := range entries {
+		if !hasFlag(e.flags, 'S') {
+			st.Unseen++
+		}
+		if e.isNew {
+			st.Recent++
+		}
+	}
+	return st, nil
+}
+
+func (b *Backend) Select(name string) (storage.MailboxStatus, error) { return b.statusOf(name) }
+func (b *Backend) Status(name string) (storage.MailboxStatus, error) { return b.statusOf(name) }
@@ -0,0 +1,62 @@
+package maildir
+
+import (
+	"bytes"
+	"net/mail"
+	"os"
+	"time"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// resolveSet narrows mailbox's entries to those named by set, a sequence or
+// UID set depending on useUID, in the same seq order scan produced them in.
+func (b *Backend) resolveSet(mailbox, set string, useUID bool) ([]entry, error) {
+	all, err := b.scan(mailbox)
+	if err != nil {
+		return nil, err
+	}
+	if set == "1:*" || set == "*" {
+		return all, nil
+	}
+
+	var matched []entry
+	for _, e := range all {
+		id := e.seq
+		if useUID {
+			id = e.uid
+		}
+		if storage.SequenceSetContains(set, id) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, nil
+}
+
+// loadMessage reads e's file and header-derived fields into a storage.Message.
+func loadMessage(e entry) (storage.Message, error) {
+	raw, err := os.ReadFile(e.path)
+	if err != nil {
+		return storage.Message{}, err
+	}
+	msg := storage.Message{
+		UID: e.uid, Seq: e.seq,
+		RawMessage: string(raw),
+		Flags:      decodeFlags(e.flags),
+	}
+	if parsed, err := mail.ReadMessage(bytes.NewReader(raw)); err == nil {
+		msg.Subject = parsed.Header.Get("Subject")
+		msg.Sender = parsed.Header.Get("From")
+		msg.Recipient = parsed.Header.Get("To")
+		msg.DateSent = parsed.Header.Get("Date")
+	}
+	info, err := os.Stat(e.path)
+	if err == nil {
+		msg.InternalDate = info.ModTime().Format(time.RFC1123Z)
+		if msg.DateSent == "" {
+			msg.DateSent = msg.InternalDate
+		}
+	}
+	msg.MIMEStructure = storage.MarshalMIME(storage.ParseMIME(raw))
+	return msg, nil
+}
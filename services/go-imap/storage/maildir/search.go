@@ -0,0 +1,26 @@
+package maildir
+
+import "github.com/Aravinda-HWK/silver/services/go-imap/storage"
+
+// Search implements SEARCH/UID SEARCH/THREAD/UID THREAD by loading every
+// message in mailbox and evaluating criteria in memory. Unlike
+// storage/sqlite there's no query engine underneath to push criteria down
+// into, so every SEARCH here is a full mailbox scan.
+func (b *Backend) Search(mailbox string, criteria storage.SearchCriterion) ([]storage.Message, error) {
+	entries, err := b.scan(mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []storage.Message
+	for _, e := range entries {
+		msg, err := loadMessage(e)
+		if err != nil {
+			continue
+		}
+		if criteria.Match(&msg) {
+			results = append(results, msg)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,210 @@
+package maildir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entry is one message file found by scan, with its IMAP identity resolved
+// against the mailbox's .uidlist.
+type entry struct {
+	uid     int
+	seq     int
+	base    string // filename up to (not including) ":2,"
+	flags   string // the letters after ":2,", e.g. "FS"
+	dir     string // "cur" or "new"
+	isNew   bool
+	path    string
+}
+
+// splitMaildirName separates a Maildir filename into its stable base and
+// its ":2,<flags>" info suffix, per the Maildir flag convention (D Draft, F
+// Flagged, R Replied/Answered, S Seen, T Trashed/Deleted).
+func splitMaildirName(name string) (base, flags string) {
+	if i := strings.Index(name, ":2,"); i != -1 {
+		return name[:i], name[i+3:]
+	}
+	return name, ""
+}
+
+func hasFlag(flags string, letter byte) bool {
+	return strings.IndexByte(flags, letter) != -1
+}
+
+var flagLetters = map[string]byte{
+	"\\Seen":     'S',
+	"\\Answered": 'R',
+	"\\Flagged":  'F',
+	"\\Deleted":  'T',
+	"\\Draft":    'D',
+}
+
+// encodeFlags renders IMAP flags as a sorted Maildir flag-letter string;
+// unknown (keyword) flags are dropped, since Maildir's info suffix has no
+// room for arbitrary keywords.
+func encodeFlags(flags []string) string {
+	var letters []byte
+	for _, f := range flags {
+		if l, ok := flagLetters[f]; ok {
+			letters = append(letters, l)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+	return string(letters)
+}
+
+// decodeFlags is encodeFlags' inverse.
+func decodeFlags(letters string) []string {
+	var flags []string
+	for name, l := range flagLetters {
+		if hasFlag(letters, l) {
+			flags = append(flags, name)
+		}
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// uidlistPath is the sidecar file mapping each message's stable base name
+// to the UID this server assigned it, plus the mailbox's UIDVALIDITY/UIDNEXT.
+func (b *Backend) uidlistPath(mailbox string) string {
+	return filepath.Join(b.mailboxDir(mailbox), ".uidlist")
+}
+
+// loadUIDList reads uidvalidity, uidnext and the base->uid map. It returns
+// uidValidity 0 when the mailbox has no .uidlist yet; its callers (uidState,
+// scanLocked) then pick a fresh one with newUIDValidity.
+func (b *Backend) loadUIDList(mailbox string) (uidValidity, uidNext int, byBase map[string]int) {
+	byBase = map[string]int{}
+	data, err := os.ReadFile(b.uidlistPath(mailbox))
+	if err != nil {
+		return 0, 1, byBase
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) > 0 {
+		fmt.Sscanf(lines[0], "%d %d", &uidValidity, &uidNext)
+	}
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		uid, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		byBase[parts[1]] = uid
+	}
+	return uidValidity, uidNext, byBase
+}
+
+func (b *Backend) saveUIDList(mailbox string, uidValidity, uidNext int, byBase map[string]int) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d %d\n", uidValidity, uidNext)
+	for base, uid := range byBase {
+		fmt.Fprintf(&sb, "%d %s\n", uid, base)
+	}
+	return os.WriteFile(b.uidlistPath(mailbox), []byte(sb.String()), 0o644)
+}
+
+// uidState returns a mailbox's current UIDVALIDITY/UIDNEXT without a full
+// scan, for ListMailboxes/Status.
+func (b *Backend) uidState(mailbox string) (uidValidity, uidNext int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	uidValidity, uidNext, _ = b.loadUIDList(mailbox)
+	if uidValidity == 0 {
+		uidValidity = newUIDValidity()
+		b.saveUIDList(mailbox, uidValidity, uidNext, map[string]int{})
+	}
+	return uidValidity, uidNext
+}
+
+// newUIDValidity picks a UIDVALIDITY that's new each time a mailbox's
+// .uidlist has to be recreated (e.g. first scan after CreateMailbox). It is
+// the current Unix time in seconds, so a mailbox re-created in a later
+// second than its previous incarnation gets a different value; that is
+// exactly when the .uidlist that would otherwise remember the old value is
+// gone too.
+func newUIDValidity() int {
+	return int(time.Now().Unix()&0x7fffffff) | 1
+}
+
+// scan lists every message in mailbox (cur and new), assigning UIDs to any
+// file not already in .uidlist and persisting the updated list. Entries are
+// returned in UID order with seq numbers 1..N, matching the ordering
+// resolveSet/Fetch/Store expect. Locked so two concurrent callers (e.g. a
+// SELECT racing an APPEND) can't both read the same uidNext and hand out
+// duplicate UIDs.
+func (b *Backend) scan(mailbox string) ([]entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.scanLocked(mailbox)
+}
+
+// scanLocked is scan's body, callable by Append while it already holds mu
+// so the delivery and the UID assignment it triggers happen atomically.
+func (b *Backend) scanLocked(mailbox string) ([]entry, error) {
+	if !b.mailboxExists(mailbox) {
+		return nil, fmt.Errorf("mailbox does not exist: %s", mailbox)
+	}
+	uidValidity, uidNext, byBase := b.loadUIDList(mailbox)
+	if uidValidity == 0 {
+		uidValidity = newUIDValidity()
+	}
+
+	var entries []entry
+	for _, dir := range []string{"cur", "new"} {
+		names, err := readDirNames(filepath.Join(b.mailboxDir(mailbox), dir))
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			base, flags := splitMaildirName(name)
+			uid, ok := byBase[base]
+			if !ok {
+				uid = uidNext
+				uidNext++
+				byBase[base] = uid
+			}
+			entries = append(entries, entry{
+				uid: uid, base: base, flags: flags, dir: dir,
+				isNew: dir == "new",
+				path:  filepath.Join(b.mailboxDir(mailbox), dir, name),
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].uid < entries[j].uid })
+	for i := range entries {
+		entries[i].seq = i + 1
+	}
+
+	if err := b.saveUIDList(mailbox, uidValidity, uidNext, byBase); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MIMEPart is one node of a message's MIME structure tree. It is computed
+// once per message by ParseMIME (at APPEND time and sample-email seeding)
+// and cached as JSON in a backend's per-message storage, so FETCH never has
+// to re-walk multipart boundaries: it just unmarshals the tree and slices
+// the cached byte offsets out of the raw message.
+type MIMEPart struct {
+	Type              string            // top-level media type, e.g. "TEXT", "MULTIPART"
+	Subtype           string            // e.g. "PLAIN", "MIXED"
+	Params            map[string]string `json:",omitempty"` // Content-Type parameters
+	ID                string            `json:",omitempty"` // Content-ID
+	Description       string            `json:",omitempty"` // Content-Description
+	Encoding          string            // Content-Transfer-Encoding, defaults to "7BIT"
+	Disposition       string            `json:",omitempty"`
+	DispositionParams map[string]string `json:",omitempty"`
+	HeaderOffset      int               // byte offset of this part's header block in the raw message
+	HeaderLength      int
+	BodyOffset        int // byte offset of this part's body in the raw message
+	BodyLength        int
+	Lines             int         // body line count, meaningful for TEXT parts
+	Children          []*MIMEPart `json:",omitempty"` // populated for MULTIPART parts
+}
+
+// ParseMIME walks raw as a MIME message and returns its part tree, rooted
+// at the top-level entity. Leaf parts carry byte offsets into raw rather
+// than copies of their content, so the tree can be cached independently of
+// how the message is later stored or re-read.
+func ParseMIME(raw []byte) *MIMEPart {
+	return parseMIMEPart(raw, 0, len(raw))
+}
+
+// parseMIMEPart parses the MIME entity occupying raw[start:end] (a header
+// block, a blank line, then a body) and recurses into multipart children.
+func parseMIMEPart(raw []byte, start, end int) *MIMEPart {
+	headerEnd, bodyStart := splitHeaderBlock(raw, start, end)
+	header := parseHeaderBlock(raw[start:headerEnd])
+
+	typ, subtype, params := "TEXT", "PLAIN", map[string]string{}
+	if mediaType, mparams, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil && mediaType != "" {
+		params = mparams
+		if i := strings.Index(mediaType, "/"); i != -1 {
+			typ, subtype = mediaType[:i], mediaType[i+1:]
+		} else {
+			typ = mediaType
+		}
+		typ, subtype = strings.ToUpper(typ), strings.ToUpper(subtype)
+	}
+
+	encoding := strings.ToUpper(header.Get("Content-Transfer-Encoding"))
+	if encoding == "" {
+		encoding = "7BIT"
+	}
+
+	part := &MIMEPart{
+		Type:         typ,
+		Subtype:      subtype,
+		Params:       params,
+		ID:           header.Get("Content-Id"),
+		Description:  header.Get("Content-Description"),
+		Encoding:     encoding,
+		HeaderOffset: start,
+		HeaderLength: headerEnd - start,
+		BodyOffset:   bodyStart,
+		BodyLength:   end - bodyStart,
+	}
+	if disp, dparams, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && disp != "" {
+		part.Disposition = strings.ToUpper(disp)
+		part.DispositionParams = dparams
+	}
+
+	if typ == "MULTIPART" && params["boundary"] != "" {
+		part.Children = splitMultipart(raw, bodyStart, end, params["boundary"])
+	} else {
+		part.Lines = bytes.Count(raw[bodyStart:end], []byte("\n"))
+	}
+	return part
+}
+
+// splitHeaderBlock locates the blank line separating headers from body
+// within raw[start:end] and returns the offset just past the header block
+// (headerEnd) and the offset the body starts at (bodyStart); they differ
+// by the length of the blank-line delimiter itself.
+func splitHeaderBlock(raw []byte, start, end int) (headerEnd, bodyStart int) {
+	region := raw[start:end]
+	if i := bytes.Index(region, []byte("\r\n\r\n")); i != -1 {
+		return start + i, start + i + 4
+	}
+	if i := bytes.Index(region, []byte("\n\n")); i != -1 {
+		return start + i, start + i + 2
+	}
+	return end, end
+}
+
+func parseHeaderBlock(b []byte) textproto.MIMEHeader {
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(b)))
+	header, err := tp.ReadMIMEHeader()
+	if header == nil && err != nil {
+		return textproto.MIMEHeader{}
+	}
+	return header
+}
+
+// splitMultipart splits raw[start:end] (a multipart body) on boundary into
+// its constituent parts and recursively parses each one.
+func splitMultipart(raw []byte, start, end int, boundary string) []*MIMEPart {
+	delim := []byte("--" + boundary)
+	region := raw[start:end]
+
+	var bounds []int
+	for i := 0; i < len(region); {
+		idx := bytes.Index(region[i:], delim)
+		if idx == -1 {
+			break
+		}
+		bounds = append(bounds, start+i+idx)
+		i += idx + len(delim)
+	}
+
+	var children []*MIMEPart
+	for i := 0; i < len(bounds)-1; i++ {
+		partStart := bounds[i] + len(delim)
+		if partStart < end && raw[partStart] == '\r' {
+			partStart++
+		}
+		if partStart < end && raw[partStart] == '\n' {
+			partStart++
+		}
+		partEnd := bounds[i+1]
+		if partStart >= partEnd {
+			continue
+		}
+		children = append(children, parseMIMEPart(raw, partStart, partEnd))
+	}
+	return children
+}
+
+// MarshalMIME serializes a part tree for caching alongside a message.
+func MarshalMIME(part *MIMEPart) string {
+	b, err := json.Marshal(part)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// UnmarshalMIME deserializes a part tree cached by MarshalMIME, falling
+// back to a fresh parse of raw when the cache is empty or predates caching
+// (e.g. rows seeded by an older version of this server).
+func UnmarshalMIME(cached string, raw []byte) *MIMEPart {
+	if cached != "" {
+		var part MIMEPart
+		if err := json.Unmarshal([]byte(cached), &part); err == nil {
+			return &part
+		}
+	}
+	return ParseMIME(raw)
+}
+
+// BodyStructure renders part as an IMAP body structure parenthesized list
+// (RFC 3501 7.4.2). extended includes the BODYSTRUCTURE-only extension data
+// (disposition/language/location); bare BODY omits it.
+func BodyStructure(part *MIMEPart, extended bool) string {
+	if part.Type == "MULTIPART" {
+		var sb strings.Builder
+		sb.WriteString("(")
+		for _, child := range part.Children {
+			sb.WriteString(BodyStructure(child, extended))
+		}
+		sb.WriteString(fmt.Sprintf(" %s", imapQuote(part.Subtype)))
+		if extended {
+			sb.WriteString(" " + paramList(part.Params))
+			sb.WriteString(" " + dispositionList(part))
+			sb.WriteString(" NIL NIL")
+		}
+		sb.WriteString(")")
+		return sb.String()
+	}
+
+	fields := []string{
+		imapQuote(part.Type),
+		imapQuote(part.Subtype),
+		paramList(part.Params),
+		imapQuoteOrNil(part.ID),
+		imapQuoteOrNil(part.Description),
+		imapQuote(part.Encoding),
+		strconv.Itoa(part.BodyLength),
+	}
+	if part.Type == "TEXT" {
+		fields = append(fields, strconv.Itoa(part.Lines))
+	}
+
+	result := "(" + strings.Join(fields, " ")
+	if extended {
+		result += " NIL " + dispositionList(part) + " NIL NIL"
+	}
+	return result + ")"
+}
+
+func paramList(params map[string]string) string {
+	if len(params) == 0 {
+		return "NIL"
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		pairs = append(pairs, imapQuote(k), imapQuote(params[k]))
+	}
+	return "(" + strings.Join(pairs, " ") + ")"
+}
+
+func dispositionList(part *MIMEPart) string {
+	if part.Disposition == "" {
+		return "NIL"
+	}
+	return "(" + imapQuote(part.Disposition) + " " + paramList(part.DispositionParams) + ")"
+}
+
+func imapQuote(s string) string {
+	return fmt.Sprintf("%q", s)
+}
+
+func imapQuoteOrNil(s string) string {
+	if s == "" {
+		return "NIL"
+	}
+	return imapQuote(s)
+}
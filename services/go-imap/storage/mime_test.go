@@ -0,0 +1,99 @@
+package storage
+
+import "testing"
+
+func TestParseMIMESinglePart(t *testing.T) {
+	raw := []byte("From: a@x\r\nTo: b@x\r\nContent-Type: text/plain\r\n\r\nhello\r\nworld\r\n")
+	part := ParseMIME(raw)
+
+	if part.Type != "TEXT" || part.Subtype != "PLAIN" {
+		t.Fatalf("Type/Subtype = %s/%s, want TEXT/PLAIN", part.Type, part.Subtype)
+	}
+	if part.Encoding != "7BIT" {
+		t.Fatalf("Encoding = %s, want default 7BIT", part.Encoding)
+	}
+	if got := string(raw[part.BodyOffset : part.BodyOffset+part.BodyLength]); got != "hello\r\nworld\r\n" {
+		t.Fatalf("body = %q, want %q", got, "hello\r\nworld\r\n")
+	}
+	if part.Lines != 2 {
+		t.Fatalf("Lines = %d, want 2", part.Lines)
+	}
+}
+
+func TestParseMIMEMultipart(t *testing.T) {
+	raw := []byte("Content-Type: multipart/mixed; boundary=B\r\n\r\n" +
+		"--B\r\nContent-Type: text/plain\r\n\r\npart one\r\n" +
+		"--B\r\nContent-Type: text/html\r\n\r\n<p>part two</p>\r\n" +
+		"--B--\r\n")
+	part := ParseMIME(raw)
+
+	if part.Type != "MULTIPART" || part.Subtype != "MIXED" {
+		t.Fatalf("Type/Subtype = %s/%s, want MULTIPART/MIXED", part.Type, part.Subtype)
+	}
+	if len(part.Children) != 2 {
+		t.Fatalf("got %d children, want 2", len(part.Children))
+	}
+	if part.Children[0].Subtype != "PLAIN" || part.Children[1].Subtype != "HTML" {
+		t.Fatalf("children = %s, %s; want PLAIN, HTML", part.Children[0].Subtype, part.Children[1].Subtype)
+	}
+}
+
+func TestUnmarshalMIMEFallsBackToParse(t *testing.T) {
+	raw := []byte("Content-Type: text/plain\r\n\r\nhi\r\n")
+	part := UnmarshalMIME("", raw)
+	if part.Type != "TEXT" {
+		t.Fatalf("Type = %s, want TEXT for an empty cache falling back to ParseMIME", part.Type)
+	}
+
+	cached := MarshalMIME(ParseMIME(raw))
+	roundTripped := UnmarshalMIME(cached, raw)
+	if roundTripped.Type != part.Type || roundTripped.Subtype != part.Subtype {
+		t.Fatalf("round-tripped part = %+v, want to match freshly parsed %+v", roundTripped, part)
+	}
+}
+
+func TestSectionData(t *testing.T) {
+	raw := []byte("Content-Type: multipart/mixed; boundary=B\r\nSubject: hi\r\n\r\n" +
+		"--B\r\nContent-Type: text/plain\r\n\r\npart one\r\n" +
+		"--B\r\nContent-Type: text/html\r\n\r\n<p>part two</p>\r\n" +
+		"--B--\r\n")
+	root := ParseMIME(raw)
+
+	whole, ok := SectionData(root, raw, "")
+	if !ok || string(whole) != string(raw) {
+		t.Fatalf("SectionData(\"\") = %q, ok=%v, want the full message", whole, ok)
+	}
+
+	header, ok := SectionData(root, raw, "HEADER")
+	if !ok || string(header) != "Content-Type: multipart/mixed; boundary=B\r\nSubject: hi\r\n\r\n" {
+		t.Fatalf("SectionData(HEADER) = %q, ok=%v", header, ok)
+	}
+
+	part1, ok := SectionData(root, raw, "1")
+	if !ok || string(part1) != "Content-Type: text/plain\r\n\r\npart one\r\n" {
+		t.Fatalf("SectionData(\"1\") = %q, ok=%v", part1, ok)
+	}
+
+	part1Text, ok := SectionData(root, raw, "1.TEXT")
+	if !ok || string(part1Text) != "part one\r\n" {
+		t.Fatalf("SectionData(\"1.TEXT\") = %q, ok=%v", part1Text, ok)
+	}
+
+	if _, ok := SectionData(root, raw, "99"); ok {
+		t.Fatalf("SectionData(\"99\") should fail: no such part")
+	}
+}
+
+func TestSectionDataHeaderFieldsFollowsFoldedLines(t *testing.T) {
+	raw := []byte("Subject: hi\r\nReferences: <a@x>\r\n <b@x>\r\n <c@x>\r\nTo: x@y\r\n\r\nbody\r\n")
+	root := ParseMIME(raw)
+
+	data, ok := SectionData(root, raw, "HEADER.FIELDS (REFERENCES)")
+	if !ok {
+		t.Fatalf("SectionData(HEADER.FIELDS (REFERENCES)) failed")
+	}
+	want := "References: <a@x>\r\n <b@x>\r\n <c@x>\r\n\r\n"
+	if string(data) != want {
+		t.Fatalf("filtered header = %q, want %q (folded continuation lines must stay with References)", data, want)
+	}
+}
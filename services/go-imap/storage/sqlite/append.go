@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"bytes"
+	"net/mail"
+	"time"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Append implements APPEND (RFC 3501 6.3.11): raw is the full message
+// literal already read off the wire by imap/handlers; this just parses its
+// headers for the denormalized subject/sender/recipient columns, caches its
+// MIME structure, and allocates a UID in folder.
+func (b *Backend) Append(folder string, raw []byte, flags []string, internalDate time.Time) (uid int, uidValidity int, err error) {
+	if err := b.db.QueryRow("SELECT uidvalidity FROM folders WHERE name = ?", folder).Scan(&uidValidity); err != nil {
+		return 0, 0, storage.ErrMailboxNotFound
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	var subject, from, to, dateSent string
+	if err == nil {
+		subject = msg.Header.Get("Subject")
+		from = msg.Header.Get("From")
+		to = msg.Header.Get("To")
+		dateSent = msg.Header.Get("Date")
+	}
+	if dateSent == "" {
+		dateSent = internalDate.Format(time.RFC1123Z)
+	}
+
+	uid, err = b.allocateUID(folder)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	mimeStructure := storage.MarshalMIME(storage.ParseMIME(raw))
+	res, err := b.db.Exec(
+		`INSERT INTO mails (uid, subject, sender, recipient, date_sent, internal_date, raw_message, mime_structure, folder)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		uid, subject, from, to, dateSent, internalDate.Format(time.RFC1123Z), string(raw), mimeStructure, folder,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(flags) > 0 {
+		mailID, _ := res.LastInsertId()
+		addFlags(b.db, int(mailID), flags)
+	}
+
+	return uid, uidValidity, nil
+}
@@ -0,0 +1,29 @@
+package sqlite
+
+import "golang.org/x/crypto/bcrypt"
+
+// Authenticate checks username/password against the users table, seeded by
+// AddUser (the "adduser" admin CLI, see main.go).
+func (b *Backend) Authenticate(username, password string) bool {
+	var hash string
+	if err := b.db.QueryRow("SELECT password_hash FROM users WHERE username = ?", username).Scan(&hash); err != nil {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// AddUser creates or updates a login in the users table, hashing password
+// with bcrypt. It's exported for the standalone "adduser" CLI subcommand,
+// which manages accounts without starting the IMAP listeners.
+func (b *Backend) AddUser(username, password, mailboxRoot string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	_, err = b.db.Exec(
+		`INSERT INTO users (username, password_hash, mailbox_root) VALUES (?, ?, ?)
+		 ON CONFLICT(username) DO UPDATE SET password_hash = excluded.password_hash, mailbox_root = excluded.mailbox_root`,
+		username, string(hash), mailboxRoot,
+	)
+	return err
+}
@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Copy implements COPY/UID COPY (RFC 3501 6.4.7/6.4.8), running the whole
+// duplication in one transaction so a failure partway through never leaves
+// destFolder with a partial copy.
+func (b *Backend) Copy(srcFolder, destFolder, seqset string, useUID bool) (storage.CopyResult, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return storage.CopyResult{}, err
+	}
+	defer tx.Rollback()
+
+	_, _, result, err := b.copyInto(tx, srcFolder, destFolder, seqset, useUID)
+	if err != nil {
+		return storage.CopyResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return storage.CopyResult{}, err
+	}
+	return result, nil
+}
+
+// Move implements MOVE/UID MOVE (RFC 6851): copies the named messages into
+// destFolder exactly like Copy, then removes the originals, reporting them
+// as expunged in descending sequence order so earlier removals don't shift
+// the sequence numbers of ones still to be reported. The copy-in and the
+// delete-out run inside one transaction, so a client never observes (or a
+// crash never leaves behind) a message that's been duplicated into
+// destFolder but not yet removed from srcFolder, or vice versa.
+func (b *Backend) Move(srcFolder, destFolder, seqset string, useUID bool) (storage.CopyResult, []storage.ExpungeResult, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return storage.CopyResult{}, nil, err
+	}
+	defer tx.Rollback()
+
+	refs, _, result, err := b.copyInto(tx, srcFolder, destFolder, seqset, useUID)
+	if err != nil {
+		return storage.CopyResult{}, nil, err
+	}
+
+	var expunged []storage.ExpungeResult
+	for i := len(refs) - 1; i >= 0; i-- {
+		if _, err := tx.Exec("DELETE FROM mail_flags WHERE mail_id = ?", refs[i].id); err != nil {
+			return storage.CopyResult{}, nil, err
+		}
+		if _, err := tx.Exec("DELETE FROM mails WHERE id = ?", refs[i].id); err != nil {
+			return storage.CopyResult{}, nil, err
+		}
+		expunged = append(expunged, storage.ExpungeResult{Seq: refs[i].seq})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.CopyResult{}, nil, err
+	}
+	return result, expunged, nil
+}
+
+// copyInto is the shared core of Copy and Move: it duplicates every message
+// named by seqset into destFolder, carrying over its flags, and returns the
+// source rows (so Move can expunge them) alongside the COPYUID result. It
+// takes a querier rather than using b.db directly so both Copy and Move can
+// run it inside their own transaction.
+func (b *Backend) copyInto(q querier, srcFolder, destFolder, seqset string, useUID bool) ([]mailRef, int, storage.CopyResult, error) {
+	var destValidity int
+	if err := q.QueryRow("SELECT uidvalidity FROM folders WHERE name = ?", destFolder).Scan(&destValidity); err != nil {
+		return nil, 0, storage.CopyResult{}, storage.ErrMailboxNotFound
+	}
+
+	refs, err := resolveSet(q, srcFolder, seqset, useUIDColumn(useUID))
+	if err != nil {
+		return nil, 0, storage.CopyResult{}, err
+	}
+
+	result := storage.CopyResult{DestUIDValidity: destValidity}
+	for _, m := range refs {
+		var subject, sender, recipient, dateSent, internalDate, rawMessage string
+		err := q.QueryRow(
+			"SELECT subject, sender, recipient, date_sent, internal_date, raw_message FROM mails WHERE id = ?", m.id,
+		).Scan(&subject, &sender, &recipient, &dateSent, &internalDate, &rawMessage)
+		if err != nil {
+			return nil, 0, storage.CopyResult{}, err
+		}
+
+		destUID, err := allocateUIDIn(q, destFolder)
+		if err != nil {
+			return nil, 0, storage.CopyResult{}, err
+		}
+		res, err := q.Exec(
+			`INSERT INTO mails (uid, subject, sender, recipient, date_sent, internal_date, raw_message, folder)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			destUID, subject, sender, recipient, dateSent, internalDate, rawMessage, destFolder,
+		)
+		if err != nil {
+			return nil, 0, storage.CopyResult{}, err
+		}
+		destID, err := res.LastInsertId()
+		if err != nil {
+			return nil, 0, storage.CopyResult{}, err
+		}
+		if err := addFlags(q, int(destID), flagsFor(q, m.id)); err != nil {
+			return nil, 0, storage.CopyResult{}, err
+		}
+
+		result.SrcUIDs = append(result.SrcUIDs, m.uid)
+		result.DestUIDs = append(result.DestUIDs, destUID)
+	}
+
+	return refs, destValidity, result, nil
+}
+
+// Expunge implements EXPUNGE/UID EXPUNGE (RFC 3501 6.4.3, RFC 4315 2.1):
+// permanently removes every \Deleted message named by seqset, reporting
+// each as expunged in descending sequence order.
+func (b *Backend) Expunge(folder, seqset string, useUID bool) ([]storage.ExpungeResult, error) {
+	refs, err := resolveSet(b.db, folder, seqset, useUIDColumn(useUID))
+	if err != nil {
+		return nil, err
+	}
+
+	var toExpunge []mailRef
+	for _, m := range refs {
+		deleted := false
+		for _, f := range flagsFor(b.db, m.id) {
+			if f == "\\Deleted" {
+				deleted = true
+				break
+			}
+		}
+		if deleted {
+			toExpunge = append(toExpunge, m)
+		}
+	}
+
+	var results []storage.ExpungeResult
+	for i := len(toExpunge) - 1; i >= 0; i-- {
+		m := toExpunge[i]
+		b.db.Exec("DELETE FROM mail_flags WHERE mail_id = ?", m.id)
+		b.db.Exec("DELETE FROM mails WHERE id = ?", m.id)
+		results = append(results, storage.ExpungeResult{Seq: m.seq})
+	}
+	return results, nil
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"strconv"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Fetch implements FETCH/UID FETCH (RFC 3501 6.4.5): itemsText is rendered
+// against every message named by seqset using storage.RenderFetchItems, so
+// every backend interprets fetch-att tokens identically.
+func (b *Backend) Fetch(folder, seqset, itemsText string, useUID bool) ([]storage.FetchResult, error) {
+	refs, err := resolveSet(b.db, folder, seqset, useUIDColumn(useUID))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []storage.FetchResult
+	for _, ref := range refs {
+		var rawMsg, mimeJSON string
+		if err := b.db.QueryRow("SELECT raw_message, mime_structure FROM mails WHERE id = ?", ref.id).Scan(&rawMsg, &mimeJSON); err != nil {
+			continue
+		}
+
+		msg := storage.Message{
+			ID:            ref.id,
+			UID:           ref.uid,
+			Seq:           ref.seq,
+			RawMessage:    rawMsg,
+			MIMEStructure: mimeJSON,
+			Flags:         flagsFor(b.db, ref.id),
+		}
+
+		fields, marksSeen := storage.RenderFetchItems(&msg, itemsText)
+		if useUID {
+			// UID FETCH always reports UID even when the client didn't ask
+			// for it explicitly, per RFC 3501 6.4.5.
+			hasUID := false
+			for _, f := range fields {
+				if f.Label == "UID "+strconv.Itoa(ref.uid) {
+					hasUID = true
+					break
+				}
+			}
+			if !hasUID {
+				fields = append([]storage.FetchField{{Label: "UID " + strconv.Itoa(ref.uid)}}, fields...)
+			}
+		}
+		if marksSeen {
+			addFlags(b.db, ref.id, []string{"\\Seen"})
+		}
+
+		results = append(results, storage.FetchResult{Seq: ref.seq, UID: ref.uid, Fields: fields})
+	}
+	return results, nil
+}
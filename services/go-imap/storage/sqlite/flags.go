@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// flagsFor returns the flag list for a mail row, normalizing away the NULL
+// that GROUP_CONCAT produces for messages with no flags. It takes a querier
+// rather than a *Backend receiver so copyInto can read flags against the
+// same *sql.Tx as the rest of a Move.
+func flagsFor(q querier, mailID int) []string {
+	var flags sql.NullString
+	q.QueryRow("SELECT GROUP_CONCAT(flag, ' ') FROM mail_flags WHERE mail_id = ?", mailID).Scan(&flags)
+	if flags.String == "" {
+		return nil
+	}
+	return strings.Fields(flags.String)
+}
+
+func (b *Backend) setFlags(mailID int, flags []string) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM mail_flags WHERE mail_id = ?", mailID); err != nil {
+		return err
+	}
+	for _, f := range flags {
+		if f == "" {
+			continue
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO mail_flags (mail_id, flag) VALUES (?, ?)", mailID, f); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// addFlags likewise takes a querier so copyInto can add the copied flags
+// inside the same transaction as the rest of a Move.
+func addFlags(q querier, mailID int, flags []string) error {
+	for _, f := range flags {
+		if f == "" {
+			continue
+		}
+		if _, err := q.Exec("INSERT OR IGNORE INTO mail_flags (mail_id, flag) VALUES (?, ?)", mailID, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backend) removeFlags(mailID int, flags []string) error {
+	for _, f := range flags {
+		if f == "" {
+			continue
+		}
+		if _, err := b.db.Exec("DELETE FROM mail_flags WHERE mail_id = ? AND flag = ?", mailID, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Store implements STORE/UID STORE (RFC 3501 6.4.6): mode (FLAGS/+FLAGS/
+// -FLAGS) applied with flags against every message named by seqset.
+func (b *Backend) Store(folder, seqset string, useUID bool, mode storage.StoreMode, flags []string) ([]storage.StoreResult, error) {
+	rows, err := resolveSet(b.db, folder, seqset, useUIDColumn(useUID))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []storage.StoreResult
+	for _, m := range rows {
+		var err error
+		switch mode {
+		case storage.StoreSet:
+			err = b.setFlags(m.id, flags)
+		case storage.StoreAdd:
+			err = addFlags(b.db, m.id, flags)
+		case storage.StoreRemove:
+			err = b.removeFlags(m.id, flags)
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, storage.StoreResult{Seq: m.seq, UID: m.uid, Flags: flagsFor(b.db, m.id)})
+	}
+	return results, nil
+}
+
+func useUIDColumn(useUID bool) string {
+	if useUID {
+		return "uid"
+	}
+	return "seq"
+}
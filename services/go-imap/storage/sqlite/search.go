@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+)
+
+// Search implements SEARCH/UID SEARCH/THREAD/UID THREAD: it returns every
+// message in folder matching criteria, annotated with its current Seq/UID,
+// so imap/handlers can render SEARCH numbers or assemble THREAD trees from
+// the same result set.
+//
+// As a private optimization this backend pushes common criteria (flags,
+// SUBJECT/FROM/TO, size, date, UID set) down into a SQL WHERE clause via
+// compileCriterion; criteria it can't express in SQL (HEADER, BODY, TEXT)
+// fall back to storage.SearchCriterion.Match against the fully loaded row.
+// Either way the result is identical — compileCriterion is purely an index
+// on disk I/O, never a second source of truth for what matches.
+func (b *Backend) Search(folder string, criteria storage.SearchCriterion) ([]storage.Message, error) {
+	query := `SELECT id, uid, subject, sender, recipient, raw_message, date_sent, seq FROM (
+		SELECT m.id as id, m.uid as uid, m.subject as subject, m.sender as sender, m.recipient as recipient,
+		       m.raw_message as raw_message, m.date_sent as date_sent,
+		       ROW_NUMBER() OVER (ORDER BY m.id ASC) as seq
+		FROM mails m WHERE folder = ?
+	) t`
+	args := []interface{}{folder}
+	where, whereArgs, compiled := compileCriterion(criteria)
+	if compiled {
+		query += " WHERE " + where
+		args = append(args, whereArgs...)
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := b.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []storage.Message
+	for rows.Next() {
+		var id, uid, seq int
+		var subject, sender, recipient, rawMsg, dateSent string
+		if err := rows.Scan(&id, &uid, &subject, &sender, &recipient, &rawMsg, &dateSent, &seq); err != nil {
+			continue
+		}
+
+		msg := storage.Message{
+			ID: id, UID: uid, Seq: seq,
+			Subject: subject, Sender: sender, Recipient: recipient,
+			RawMessage: rawMsg, DateSent: dateSent,
+			Flags: flagsFor(b.db, id),
+		}
+		if !compiled && !criteria.Match(&msg) {
+			continue
+		}
+		results = append(results, msg)
+	}
+	return results, nil
+}
+
+// compileCriterion mirrors storage.SearchCriterion.Match as a SQL WHERE
+// fragment for the node types cheap to express over the mails/mail_flags
+// tables. ok is false when criterion (or one of its children) has no SQL
+// equivalent, in which case Search falls back to Match for every row.
+func compileCriterion(criterion storage.SearchCriterion) (where string, args []interface{}, ok bool) {
+	switch c := criterion.(type) {
+	case *storage.AndCriterion:
+		var clauses []string
+		for _, crit := range c.Criteria {
+			w, a, ok := compileCriterion(crit)
+			if !ok {
+				return "", nil, false
+			}
+			clauses = append(clauses, w)
+			args = append(args, a...)
+		}
+		if len(clauses) == 0 {
+			return "1=1", nil, true
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")", args, true
+
+	case *storage.OrCriterion:
+		lw, la, lok := compileCriterion(c.Left)
+		rw, ra, rok := compileCriterion(c.Right)
+		if !lok || !rok {
+			return "", nil, false
+		}
+		return "(" + lw + " OR " + rw + ")", append(la, ra...), true
+
+	case *storage.NotCriterion:
+		w, a, ok := compileCriterion(c.Criterion)
+		if !ok {
+			return "", nil, false
+		}
+		return "(NOT " + w + ")", a, true
+
+	case *storage.FlagCriterion:
+		return compileFlag(c)
+
+	case *storage.KeywordCriterion:
+		return hasFlagSQL(c.Keyword, c.Set)
+
+	case *storage.HeaderCriterion:
+		switch c.Field {
+		case "SUBJECT":
+			return "(subject LIKE ?)", []interface{}{"%" + c.Value + "%"}, true
+		case "FROM":
+			return "(sender LIKE ?)", []interface{}{"%" + c.Value + "%"}, true
+		case "TO":
+			return "(recipient LIKE ?)", []interface{}{"%" + c.Value + "%"}, true
+		}
+		// CC, BCC and generic HEADER lookups need the parsed message
+		// headers, which aren't columns on `mails`.
+		return "", nil, false
+
+	case *storage.SizeCriterion:
+		if c.Larger {
+			return "(LENGTH(raw_message) > ?)", []interface{}{c.Size}, true
+		}
+		return "(LENGTH(raw_message) < ?)", []interface{}{c.Size}, true
+
+	case *storage.DateCriterion:
+		// date_sent holds RFC1123Z text, which SQLite's date() can't parse,
+		// so date criteria are always evaluated by Match.
+		return "", nil, false
+
+	case *storage.UIDSetCriterion:
+		return compileSequenceSet(c.Set, "uid")
+
+	case *storage.SeqSetCriterion:
+		return compileSequenceSet(c.Set, "seq")
+	}
+
+	return "", nil, false
+}
+
+func compileFlag(c *storage.FlagCriterion) (string, []interface{}, bool) {
+	switch c.Flag {
+	case "ALL":
+		return "1=1", nil, true
+	case "NEW":
+		return hasFlagSQL("\\Seen", !c.Set)
+	case "OLD":
+		return "1=1", nil, true
+	case "RECENT":
+		return "1=0", nil, true
+	case "SEEN", "ANSWERED", "FLAGGED", "DELETED", "DRAFT":
+		return hasFlagSQL("\\"+systemFlagTag(c.Flag), c.Set)
+	}
+	return "", nil, false
+}
+
+// systemFlagTag maps an upper-cased criterion name (SEEN, DELETED, ...) to
+// the capitalized form stored in mail_flags (Seen, Deleted, ...).
+func systemFlagTag(flag string) string {
+	lower := strings.ToLower(flag)
+	if lower == "" {
+		return ""
+	}
+	return strings.ToUpper(lower[:1]) + lower[1:]
+}
+
+// hasFlagSQL builds a correlated EXISTS (or NOT EXISTS) check against the mail_flags
+// table, referencing the enclosing query's `id` column.
+func hasFlagSQL(flag string, set bool) (string, []interface{}, bool) {
+	if set {
+		return "(EXISTS (SELECT 1 FROM mail_flags WHERE mail_id = id AND flag = ?))", []interface{}{flag}, true
+	}
+	return "(NOT EXISTS (SELECT 1 FROM mail_flags WHERE mail_id = id AND flag = ?))", []interface{}{flag}, true
+}
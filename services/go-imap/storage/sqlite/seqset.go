@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// mailRef identifies a single message within its folder for the handlers
+// (STORE/COPY/MOVE/EXPUNGE/FETCH) that operate over a sequence or UID set.
+type mailRef struct {
+	id, uid, seq int
+}
+
+// resolveUIDSet expands a UID sequence-set (e.g. "1:4,7" or "1:*") against
+// folder into the matching mail rows, each annotated with its current
+// sequence number.
+func (b *Backend) resolveUIDSet(folder, set string) ([]mailRef, error) {
+	return resolveSet(b.db, folder, set, "uid")
+}
+
+// resolveSequenceSet is the non-UID counterpart of resolveUIDSet: set is a
+// message sequence-set instead of a UID set.
+func (b *Backend) resolveSequenceSet(folder, set string) ([]mailRef, error) {
+	return resolveSet(b.db, folder, set, "seq")
+}
+
+// resolveSet takes a querier rather than a *Backend receiver so copyInto can
+// run it against the same *sql.Tx as the rest of a Move, instead of against
+// b.db directly.
+func resolveSet(q querier, folder, set, column string) ([]mailRef, error) {
+	query := `SELECT id, uid, seq FROM (
+		SELECT id, uid, ROW_NUMBER() OVER (ORDER BY id ASC) as seq FROM mails WHERE folder = ?
+	) t WHERE `
+	args := []interface{}{folder}
+
+	if set == "1:*" {
+		query += "1=1"
+	} else {
+		bounded, err := boundOpenRanges(q, folder, set, column)
+		if err != nil {
+			return nil, err
+		}
+		where, whereArgs, ok := compileSequenceSet(bounded, column)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s set: %s", column, set)
+		}
+		query += where
+		args = append(args, whereArgs...)
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []mailRef
+	for rows.Next() {
+		var m mailRef
+		if err := rows.Scan(&m.id, &m.uid, &m.seq); err != nil {
+			continue
+		}
+		refs = append(refs, m)
+	}
+	return refs, nil
+}
+
+// boundOpenRanges rewrites every bare "*" or open-ended "n:*"/"*:n" part of
+// set into a literal number bounded by the largest column value currently in
+// use within folder (RFC 3501 6.4.5 defines "*" as "the largest number in
+// use"). set is returned unchanged if it contains no "*", so callers that
+// already handled the common "1:*" case don't pay for an extra query.
+func boundOpenRanges(q querier, folder, set, column string) (string, error) {
+	if !strings.Contains(set, "*") {
+		return set, nil
+	}
+	max, err := maxColumnValue(q, folder, column)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(set, ",")
+	for i, part := range parts {
+		if !strings.Contains(part, "*") {
+			continue
+		}
+		if part == "*" {
+			parts[i] = strconv.Itoa(max)
+			continue
+		}
+		bounds := strings.SplitN(part, ":", 2)
+		if bounds[0] == "*" {
+			bounds[0] = strconv.Itoa(max)
+		}
+		if bounds[1] == "*" {
+			bounds[1] = strconv.Itoa(max)
+		}
+		parts[i] = bounds[0] + ":" + bounds[1]
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// maxColumnValue returns the largest uid (or sequence number) currently in
+// use within folder. Sequence numbers are the folder's message count since
+// resolveSet numbers them 1..N by ROW_NUMBER(), matching the ordering
+// everywhere else in this package.
+func maxColumnValue(q querier, folder, column string) (int, error) {
+	if column == "seq" {
+		var count int
+		err := q.QueryRow("SELECT COUNT(*) FROM mails WHERE folder = ?", folder).Scan(&count)
+		return count, err
+	}
+	var max int
+	err := q.QueryRow("SELECT COALESCE(MAX(uid), 0) FROM mails WHERE folder = ?", folder).Scan(&max)
+	return max, err
+}
+
+// compileSequenceSet turns a sequence-set like "1:4,7,9:20" into a SQL
+// fragment over column. Any "*" still present is rejected: resolveSet's
+// callers bound "*" ranges against the folder's max column value via
+// boundOpenRanges before reaching here; compileCriterion (SEARCH) calls this
+// directly and falls back to in-memory matching instead.
+func compileSequenceSet(set, column string) (string, []interface{}, bool) {
+	if strings.Contains(set, "*") {
+		return "", nil, false
+	}
+	var clauses []string
+	var args []interface{}
+	for _, part := range strings.Split(set, ",") {
+		if strings.Contains(part, ":") {
+			bounds := strings.SplitN(part, ":", 2)
+			lo, err1 := strconv.Atoi(bounds[0])
+			hi, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return "", nil, false
+			}
+			clauses = append(clauses, fmt.Sprintf("(%s BETWEEN ? AND ?)", column))
+			args = append(args, lo, hi)
+		} else {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return "", nil, false
+			}
+			clauses = append(clauses, fmt.Sprintf("(%s = ?)", column))
+			args = append(args, n)
+		}
+	}
+	if len(clauses) == 0 {
+		return "1=0", nil, true
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args, true
+}
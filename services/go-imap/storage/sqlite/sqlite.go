@@ -0,0 +1,279 @@
+// Package sqlite is the SQLite-backed storage.Backend, the original
+// implementation this server shipped with before storage became a
+// pluggable interface.
+package sqlite
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Aravinda-HWK/silver/services/go-imap/storage"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Backend is the SQLite-backed storage.Backend.
+type Backend struct {
+	db *sql.DB
+}
+
+// querier is the subset of *sql.DB and *sql.Tx this package's query helpers
+// need, so they can run either standalone against b.db or, when a caller
+// (Move) needs several of them to commit or roll back together, against one
+// shared *sql.Tx.
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Open opens (creating if needed) the SQLite database at path, migrates its
+// schema, and seeds sample mail on a brand-new database.
+func Open(path string) (*Backend, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	b := &Backend{db: db}
+	if err := b.initSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+func (b *Backend) initSchema() error {
+	createTables := `
+	CREATE TABLE IF NOT EXISTS mails (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		uid INTEGER,
+		subject TEXT,
+		sender TEXT,
+		recipient TEXT,
+		date_sent TEXT,
+		internal_date TEXT,
+		raw_message TEXT,
+		mime_structure TEXT NOT NULL DEFAULT '',
+		folder TEXT DEFAULT 'INBOX'
+	);
+
+	CREATE TABLE IF NOT EXISTS mail_flags (
+		mail_id INTEGER NOT NULL,
+		flag TEXT NOT NULL,
+		PRIMARY KEY (mail_id, flag)
+	);
+
+	CREATE TABLE IF NOT EXISTS folders (
+		name TEXT PRIMARY KEY,
+		delimiter TEXT DEFAULT '/',
+		attributes TEXT DEFAULT '',
+		uidvalidity INTEGER NOT NULL DEFAULT 1,
+		uidnext INTEGER NOT NULL DEFAULT 1,
+		subscribed INTEGER NOT NULL DEFAULT 1
+	);
+
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		mailbox_root TEXT NOT NULL DEFAULT ''
+	);
+
+	INSERT OR IGNORE INTO folders (name, attributes, uidvalidity) VALUES ('INBOX', '', 1);
+	INSERT OR IGNORE INTO folders (name, attributes, uidvalidity) VALUES ('Sent', '', 2);
+	INSERT OR IGNORE INTO folders (name, attributes, uidvalidity) VALUES ('Drafts', '\Drafts', 3);
+	INSERT OR IGNORE INTO folders (name, attributes, uidvalidity) VALUES ('Trash', '\Trash', 4);
+	`
+
+	if _, err := b.db.Exec(createTables); err != nil {
+		return err
+	}
+
+	// mime_structure was added after mails already shipped; CREATE TABLE IF
+	// NOT EXISTS above only covers brand-new databases, so existing ones are
+	// migrated here. The error (duplicate column) is expected and ignored
+	// once this has already run.
+	b.db.Exec("ALTER TABLE mails ADD COLUMN mime_structure TEXT NOT NULL DEFAULT ''")
+
+	var count int
+	err := b.db.QueryRow("SELECT COUNT(*) FROM mails").Scan(&count)
+	if err == nil && count == 0 {
+		b.insertSampleEmails()
+	}
+	return nil
+}
+
+func (b *Backend) insertSampleEmails() {
+	sampleEmails := []struct {
+		subject, sender, recipient, rawMessage string
+	}{
+		{
+			"Welcome to SQLite IMAP",
+			"admin@example.com",
+			"user@example.com",
+			"From: admin@example.com\r\nTo: user@example.com\r\nSubject: Welcome to SQLite IMAP\r\nDate: " + time.Now().Format(time.RFC1123Z) + "\r\n\r\nWelcome to your SQLite IMAP server!\r\n\r\nThis is a test message.\r\n",
+		},
+		{
+			"Test Message 2",
+			"test@example.com",
+			"user@example.com",
+			"From: test@example.com\r\nTo: user@example.com\r\nSubject: Test Message 2\r\nDate: " + time.Now().Add(-24*time.Hour).Format(time.RFC1123Z) + "\r\n\r\nThis is another test message with some content.\r\n\r\nBest regards,\r\nTest User\r\n",
+		},
+	}
+
+	for _, email := range sampleEmails {
+		uid, err := b.allocateUID("INBOX")
+		if err != nil {
+			log.Println("failed to allocate UID for sample email:", err)
+			continue
+		}
+		now := time.Now().Format(time.RFC1123Z)
+		mimeStructure := storage.MarshalMIME(storage.ParseMIME([]byte(email.rawMessage)))
+		b.db.Exec("INSERT INTO mails (uid, subject, sender, recipient, date_sent, internal_date, raw_message, mime_structure, folder) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'INBOX')",
+			uid, email.subject, email.sender, email.recipient, now, now, email.rawMessage, mimeStructure)
+	}
+}
+
+// allocateUID assigns the next UID for folder, bumping its uidnext counter.
+// It runs in its own transaction so concurrent APPEND/COPY/MOVE calls
+// against the same folder never hand out the same UID twice.
+func (b *Backend) allocateUID(folder string) (int, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	uid, err := allocateUIDIn(tx, folder)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return uid, nil
+}
+
+// allocateUIDIn is allocateUID's read-modify-write against an
+// already-open querier, for callers (copyInto, via Move) that need UID
+// allocation to participate in a larger transaction instead of committing
+// on its own.
+func allocateUIDIn(q querier, folder string) (int, error) {
+	var uid int
+	if err := q.QueryRow("SELECT uidnext FROM folders WHERE name = ?", folder).Scan(&uid); err != nil {
+		return 0, err
+	}
+	if _, err := q.Exec("UPDATE folders SET uidnext = uidnext + 1 WHERE name = ?", folder); err != nil {
+		return 0, err
+	}
+	return uid, nil
+}
+
+func (b *Backend) mailboxExists(name string) bool {
+	var exists int
+	b.db.QueryRow("SELECT COUNT(*) FROM folders WHERE name = ?", name).Scan(&exists)
+	return exists > 0
+}
+
+func (b *Backend) ListMailboxes() ([]storage.Mailbox, error) {
+	rows, err := b.db.Query("SELECT name, delimiter, attributes, uidvalidity, uidnext, subscribed FROM folders ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mailboxes []storage.Mailbox
+	for rows.Next() {
+		var m storage.Mailbox
+		var subscribed int
+		if err := rows.Scan(&m.Name, &m.Delimiter, &m.Attributes, &m.UIDValidity, &m.UIDNext, &subscribed); err != nil {
+			continue
+		}
+		m.Subscribed = subscribed != 0
+		mailboxes = append(mailboxes, m)
+	}
+	return mailboxes, nil
+}
+
+func (b *Backend) CreateMailbox(name string) error {
+	if b.mailboxExists(name) {
+		return storage.ErrMailboxExists
+	}
+	var nextValidity int
+	b.db.QueryRow("SELECT COALESCE(MAX(uidvalidity), 0) + 1 FROM folders").Scan(&nextValidity)
+	_, err := b.db.Exec("INSERT INTO folders (name, uidvalidity, uidnext, subscribed) VALUES (?, ?, 1, 0)", name, nextValidity)
+	return err
+}
+
+func (b *Backend) DeleteMailbox(name string) error {
+	if strings.EqualFold(name, "INBOX") {
+		return storage.ErrInboxUndeletable
+	}
+	if !b.mailboxExists(name) {
+		return storage.ErrMailboxNotFound
+	}
+	if _, err := b.db.Exec("DELETE FROM mail_flags WHERE mail_id IN (SELECT id FROM mails WHERE folder = ?)", name); err != nil {
+		return err
+	}
+	if _, err := b.db.Exec("DELETE FROM mails WHERE folder = ?", name); err != nil {
+		return err
+	}
+	_, err := b.db.Exec("DELETE FROM folders WHERE name = ?", name)
+	return err
+}
+
+func (b *Backend) RenameMailbox(oldName, newName string) error {
+	if !b.mailboxExists(oldName) {
+		return storage.ErrMailboxNotFound
+	}
+	if b.mailboxExists(newName) {
+		return storage.ErrMailboxExists
+	}
+	if _, err := b.db.Exec("UPDATE folders SET name = ? WHERE name = ?", newName, oldName); err != nil {
+		return err
+	}
+	_, err := b.db.Exec("UPDATE mails SET folder = ? WHERE folder = ?", newName, oldName)
+	return err
+}
+
+func (b *Backend) SetSubscribed(name string, subscribed bool) error {
+	if !b.mailboxExists(name) {
+		return storage.ErrMailboxNotFound
+	}
+	flag := 0
+	if subscribed {
+		flag = 1
+	}
+	_, err := b.db.Exec("UPDATE folders SET subscribed = ? WHERE name = ?", flag, name)
+	return err
+}
+
+func (b *Backend) statusOf(name string) (storage.MailboxStatus, error) {
+	var st storage.MailboxStatus
+	st.Name = name
+	if err := b.db.QueryRow("SELECT delimiter, attributes, uidvalidity, uidnext FROM folders WHERE name = ?", name).
+		Scan(&st.Delimiter, &st.Attributes, &st.UIDValidity, &st.UIDNext); err != nil {
+		return storage.MailboxStatus{}, storage.ErrMailboxNotFound
+	}
+	b.db.QueryRow("SELECT COUNT(*) FROM mails WHERE folder = ?", name).Scan(&st.Messages)
+	b.db.QueryRow(
+		"SELECT COUNT(*) FROM mails m WHERE folder = ? AND NOT EXISTS (SELECT 1 FROM mail_flags WHERE mail_id = m.id AND flag = '\\Seen')",
+		name,
+	).Scan(&st.Unseen)
+	st.Recent = st.Unseen
+	return st, nil
+}
+
+func (b *Backend) Select(name string) (storage.MailboxStatus, error) {
+	return b.statusOf(name)
+}
+
+func (b *Backend) Status(name string) (storage.MailboxStatus, error) {
+	return b.statusOf(name)
+}
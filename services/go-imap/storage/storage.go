@@ -0,0 +1,126 @@
+// Package storage defines the mailbox persistence contract shared by every
+// backend (storage/sqlite, storage/maildir). It knows about messages,
+// flags and mailboxes; it knows nothing about IMAP wire syntax, tags or
+// connections, which stay in the imap/* packages.
+package storage
+
+import "time"
+
+// Message is one stored email, denormalized enough for both FETCH rendering
+// and SEARCH/THREAD evaluation.
+type Message struct {
+	ID            int
+	UID           int
+	Seq           int
+	Subject       string
+	Sender        string
+	Recipient     string
+	DateSent      string // RFC1123Z
+	InternalDate  string // RFC1123Z
+	RawMessage    string
+	MIMEStructure string // cached JSON from MarshalMIME, may be empty for pre-migration rows
+	Flags         []string
+}
+
+// Mailbox describes one folder's identity and UID allocation state.
+type Mailbox struct {
+	Name        string
+	Delimiter   string
+	Attributes  string
+	UIDValidity int
+	UIDNext     int
+	Subscribed  bool
+}
+
+// MailboxStatus extends Mailbox with the live counters STATUS/SELECT report.
+type MailboxStatus struct {
+	Mailbox
+	Messages int
+	Recent   int
+	Unseen   int
+}
+
+// FetchField is one data item in a FETCH response, as selected by the
+// backend's interpretation of the client's fetch-att list. Plain items
+// (FLAGS, UID, RFC822.SIZE, BODYSTRUCTURE...) carry only Label; body-section
+// items carry their payload as Data and render as an IMAP literal.
+type FetchField struct {
+	Label   string
+	Literal bool
+	Data    []byte
+}
+
+// FetchResult is the rendered item list for one message in a FETCH response.
+type FetchResult struct {
+	Seq, UID int
+	Fields   []FetchField
+}
+
+// StoreMode is the STORE operator (RFC 3501 6.4.6), independent of its
+// .SILENT suffix, which is a presentation concern handled by imap/handlers.
+type StoreMode int
+
+const (
+	StoreSet StoreMode = iota
+	StoreAdd
+	StoreRemove
+)
+
+// StoreResult reports one message's flags after a STORE operation.
+type StoreResult struct {
+	Seq, UID int
+	Flags    []string
+}
+
+// CopyResult reports the UIDs COPY/MOVE assigned in the destination mailbox,
+// for the tagged COPYUID response (RFC 4315 3).
+type CopyResult struct {
+	DestUIDValidity int
+	SrcUIDs         []int
+	DestUIDs        []int
+}
+
+// ExpungeResult is one message removed by EXPUNGE/UID EXPUNGE, identified by
+// the sequence number it had immediately before removal.
+type ExpungeResult struct {
+	Seq int
+}
+
+// Backend is the storage contract an IMAP session is driven against.
+// imap/handlers never touches SQL, Maildir files or any other on-disk
+// format directly; it only calls through this interface.
+type Backend interface {
+	ListMailboxes() ([]Mailbox, error)
+	CreateMailbox(name string) error
+	DeleteMailbox(name string) error
+	RenameMailbox(oldName, newName string) error
+	SetSubscribed(name string, subscribed bool) error
+
+	// Select returns the current status of name, or an error if it doesn't
+	// exist. It has no side effect on the backend; tracking which mailbox a
+	// session has selected is imap/session's job.
+	Select(name string) (MailboxStatus, error)
+	Status(name string) (MailboxStatus, error)
+
+	Append(folder string, raw []byte, flags []string, internalDate time.Time) (uid int, uidValidity int, err error)
+
+	// Fetch interprets items (the raw, still-parenthesized fetch-att list)
+	// against every message named by seqset, returning one FetchResult per
+	// message in mailbox order.
+	Fetch(folder, seqset, items string, useUID bool) ([]FetchResult, error)
+
+	// Store applies mode (with the given flags) to every message named by
+	// seqset and returns each message's resulting flag set.
+	Store(folder, seqset string, useUID bool, mode StoreMode, flags []string) ([]StoreResult, error)
+
+	Copy(srcFolder, destFolder, seqset string, useUID bool) (CopyResult, error)
+	Move(srcFolder, destFolder, seqset string, useUID bool) (CopyResult, []ExpungeResult, error)
+	Expunge(folder, seqset string, useUID bool) ([]ExpungeResult, error)
+
+	// Search returns every message in folder matching criteria, each
+	// annotated with its current Seq/UID so imap/handlers can render either
+	// SEARCH or THREAD from the same result set.
+	Search(folder string, criteria SearchCriterion) ([]Message, error)
+
+	Authenticate(username, password string) bool
+}
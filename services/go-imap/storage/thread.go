@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ThreadNode is one message in a THREAD response tree (RFC 5256 section
+// 4). Num is the sequence number or UID reported for this node, per
+// whether the caller is rendering THREAD or UID THREAD.
+type ThreadNode struct {
+	Num      int
+	DateSent time.Time
+	MsgID    string
+	Children []*ThreadNode
+}
+
+// BuildThreads arranges msgs (already narrowed to the messages matching a
+// SEARCH criterion) into threads using algorithm, which must be
+// "ORDEREDSUBJECT" or "REFERENCES" (RFC 5256 section 2). useUID selects
+// whether each node reports its UID or its sequence number.
+func BuildThreads(msgs []Message, algorithm string, useUID bool) []*ThreadNode {
+	var nodes []*ThreadNode
+	subjects := map[*ThreadNode]string{}
+	references := map[*ThreadNode][]string{}
+
+	for _, msg := range msgs {
+		header := headerOf(&msg)
+		num := msg.Seq
+		if useUID {
+			num = msg.UID
+		}
+		dateSent, _ := time.Parse(time.RFC1123Z, msg.DateSent)
+		node := &ThreadNode{Num: num, DateSent: dateSent, MsgID: header.Get("Message-Id")}
+		nodes = append(nodes, node)
+		subjects[node] = header.Get("Subject")
+		references[node] = referencedIDs(header)
+	}
+
+	if algorithm == "REFERENCES" {
+		return threadByReferences(nodes, references, subjects)
+	}
+	return threadByOrderedSubject(nodes, subjects)
+}
+
+// RenderThread prints node in THREAD response syntax (RFC 5256 section 4):
+// a message with a single child continues the same flat list as its
+// parent ("3 6"); a message with multiple children puts each child's
+// subtree in its own parenthesized sublist ("6 (4 23)(44 7 96)").
+func RenderThread(node *ThreadNode) string {
+	if len(node.Children) == 0 {
+		return strconv.Itoa(node.Num)
+	}
+	if len(node.Children) == 1 {
+		return strconv.Itoa(node.Num) + " " + RenderThread(node.Children[0])
+	}
+	var branches strings.Builder
+	for _, child := range node.Children {
+		branches.WriteString("(" + RenderThread(child) + ")")
+	}
+	return strconv.Itoa(node.Num) + " " + branches.String()
+}
+
+// referencedIDs returns the chain of Message-IDs a message's References
+// header names, falling back to In-Reply-To when References is absent, in
+// the order they should be walked from oldest ancestor to direct parent.
+func referencedIDs(header mail.Header) []string {
+	if refs := header.Get("References"); refs != "" {
+		return msgIDPattern.FindAllString(refs, -1)
+	}
+	if inReplyTo := header.Get("In-Reply-To"); inReplyTo != "" {
+		return msgIDPattern.FindAllString(inReplyTo, -1)
+	}
+	return nil
+}
+
+var msgIDPattern = regexp.MustCompile(`<[^<>]+>`)
+
+// container is the REFERENCES algorithm's working node (JWZ threading,
+// http://www.jwz.org/doc/threading.html, as adapted by RFC 5256 section
+// 2.2). Unlike ThreadNode, a container can be "empty" — standing in for a
+// Message-Id that's referenced by some message but didn't itself match this
+// result set — until pruneEmptyContainers removes or splices those out.
+type container struct {
+	subject  string // baseSubject of node, once node is set; "" until then
+	node     *ThreadNode
+	parent   *container
+	children []*container
+}
+
+func (c *container) addChild(child *container) {
+	child.parent = c
+	c.children = append(c.children, child)
+}
+
+// descendsFrom reports whether c is ancestor itself or one of ancestor's
+// descendants, i.e. whether making ancestor a child of c would close a
+// cycle. Message-Id chains are attacker- or bug-controlled input (a
+// References header can name any IDs in any order, including a message's
+// own later reply), so without this check a reference cycle would recurse
+// forever instead of just losing a thread link.
+func (c *container) descendsFrom(ancestor *container) bool {
+	for n := c; n != nil; n = n.parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// threadByReferences implements the REFERENCES algorithm (RFC 5256 section
+// 2.2, following JWZ): every Message-Id mentioned anywhere (as a message or
+// merely as a reference) gets a container; containers are linked parent to
+// child in References order; containers left with no message after linking
+// are pruned (dropped if they also have no children, otherwise spliced out
+// in favor of their children); and root containers sharing a base subject
+// are finally merged into one thread. Siblings at every level are ordered by
+// sent date, and the roots by the earliest sent date anywhere in each thread.
+func threadByReferences(nodes []*ThreadNode, references map[*ThreadNode][]string, subjects map[*ThreadNode]string) []*ThreadNode {
+	idTable := map[string]*container{}
+	var all []*container
+
+	getContainer := func(msgID string) *container {
+		if c, ok := idTable[msgID]; ok {
+			return c
+		}
+		c := &container{}
+		idTable[msgID] = c
+		all = append(all, c)
+		return c
+	}
+
+	for _, n := range nodes {
+		var msgContainer *container
+		if n.MsgID == "" {
+			// No Message-Id to key a shared container off of: give it one
+			// of its own so it still threads by what it references, but
+			// can never collide with another message.
+			msgContainer = &container{}
+			all = append(all, msgContainer)
+		} else {
+			msgContainer = getContainer(n.MsgID)
+			if msgContainer.node != nil {
+				// Two messages claiming the same Message-Id: don't let
+				// the second overwrite the first's container (which
+				// would silently drop the first from the result), give
+				// it an unshared one instead.
+				msgContainer = &container{}
+				all = append(all, msgContainer)
+			}
+		}
+		msgContainer.node = n
+		msgContainer.subject = baseSubject(subjects[n])
+
+		var parent *container
+		for _, ref := range references[n] {
+			c := getContainer(ref)
+			if parent != nil && c != parent && c.parent == nil && !parent.descendsFrom(c) {
+				parent.addChild(c)
+			}
+			parent = c
+		}
+		if parent != nil && parent != msgContainer && msgContainer.parent == nil && !parent.descendsFrom(msgContainer) {
+			parent.addChild(msgContainer)
+		}
+	}
+
+	var roots []*container
+	for _, c := range all {
+		if c.parent == nil {
+			roots = append(roots, c)
+		}
+	}
+	roots = pruneEmptyContainers(roots)
+	roots = mergeRootsBySubject(roots)
+
+	result := make([]*ThreadNode, len(roots))
+	for i, c := range roots {
+		result[i] = attachContainerChildren(c)
+	}
+	sortThreadTree(result)
+	var earliest func(n *ThreadNode) time.Time
+	earliest = func(n *ThreadNode) time.Time {
+		t := n.DateSent
+		for _, child := range n.Children {
+			if d := earliest(child); d.Before(t) {
+				t = d
+			}
+		}
+		return t
+	}
+	sort.SliceStable(result, func(i, j int) bool { return earliest(result[i]).Before(earliest(result[j])) })
+	return result
+}
+
+// pruneEmptyContainers recursively drops containers with no message and no
+// children, and splices containers with no message but children up into
+// their parent's child list in their place — the JWZ "container trimming"
+// pass that turns the id_table's placeholder containers (created only to
+// hold a spot for a referenced-but-absent Message-Id) back into a tree of
+// real messages.
+func pruneEmptyContainers(siblings []*container) []*container {
+	var kept []*container
+	for _, c := range siblings {
+		c.children = pruneEmptyContainers(c.children)
+		if c.node == nil {
+			if len(c.children) == 0 {
+				continue
+			}
+			for _, child := range c.children {
+				child.parent = c.parent
+			}
+			kept = append(kept, c.children...)
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// mergeRootsBySubject implements the REFERENCES algorithm's final "gather
+// root set by subject" step: two root threads with the same base subject
+// almost always belong together (e.g. a reply whose parent was never
+// fetched, so its References chain never reached the first thread), so the
+// later one is folded in as a child of the first rather than left as its
+// own separate top-level thread. Roots with no subject are left alone.
+func mergeRootsBySubject(roots []*container) []*container {
+	firstBySubject := map[string]*container{}
+	for _, c := range roots {
+		if c.subject == "" {
+			continue
+		}
+		if _, ok := firstBySubject[c.subject]; !ok {
+			firstBySubject[c.subject] = c
+		}
+	}
+
+	var merged []*container
+	for _, c := range roots {
+		first := firstBySubject[c.subject]
+		if c.subject == "" || first == c {
+			merged = append(merged, c)
+			continue
+		}
+		first.addChild(c)
+	}
+	return merged
+}
+
+// attachContainerChildren copies a pruned container tree's shape onto the
+// ThreadNode it wraps (containers exist only to build the tree; the
+// response is rendered from the ThreadNodes they point to).
+func attachContainerChildren(c *container) *ThreadNode {
+	c.node.Children = make([]*ThreadNode, len(c.children))
+	for i, child := range c.children {
+		c.node.Children[i] = attachContainerChildren(child)
+	}
+	return c.node
+}
+
+// sortThreadTree orders nodes, and every level of their descendants, by
+// sent date ascending (RFC 5256 section 2's ordering rule applies at every
+// level, not just the root set).
+func sortThreadTree(nodes []*ThreadNode) {
+	sortThreadsByDate(nodes)
+	for _, n := range nodes {
+		sortThreadTree(n.Children)
+	}
+}
+
+// threadByOrderedSubject implements the ORDEREDSUBJECT algorithm (RFC
+// 5256 section 2.1): messages are grouped by base subject, each group is
+// ordered by sent date and flattened into a single chain, and the groups
+// themselves are ordered by the sent date of their first message.
+func threadByOrderedSubject(nodes []*ThreadNode, subjects map[*ThreadNode]string) []*ThreadNode {
+	groups := map[string][]*ThreadNode{}
+	var order []string
+	for _, n := range nodes {
+		base := baseSubject(subjects[n])
+		if _, seen := groups[base]; !seen {
+			order = append(order, base)
+		}
+		groups[base] = append(groups[base], n)
+	}
+
+	var roots []*ThreadNode
+	for _, base := range order {
+		group := groups[base]
+		sortThreadsByDate(group)
+		for i := 1; i < len(group); i++ {
+			group[i-1].Children = []*ThreadNode{group[i]}
+		}
+		roots = append(roots, group[0])
+	}
+
+	sort.SliceStable(roots, func(i, j int) bool { return roots[i].DateSent.Before(roots[j].DateSent) })
+	return roots
+}
+
+func sortThreadsByDate(nodes []*ThreadNode) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].DateSent.Before(nodes[j].DateSent) })
+}
+
+var subjectPrefixPattern = regexp.MustCompile(`(?i)^(re|fw|fwd)(\[\d+\])?\s*:\s*`)
+var subjectTrailerPattern = regexp.MustCompile(`(?i)\s*\(fwd\)\s*$`)
+
+// baseSubject strips reply/forward markers per RFC 5256 section 2.1 so
+// "Re: Re: budget" and "budget" land in the same thread group.
+func baseSubject(subject string) string {
+	for {
+		trimmed := subjectPrefixPattern.ReplaceAllString(subject, "")
+		trimmed = subjectTrailerPattern.ReplaceAllString(trimmed, "")
+		trimmed = strings.TrimSpace(trimmed)
+		if trimmed == subject {
+			return strings.ToLower(trimmed)
+		}
+		subject = trimmed
+	}
+}
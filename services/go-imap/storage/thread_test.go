@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// msg builds a minimal Message whose headers carry exactly what
+// threadByReferences needs: a Message-Id, optional References, a Subject,
+// and a Date to sort by.
+func msg(seq int, msgID, references, subject string, sentAt time.Time) Message {
+	raw := "Message-Id: " + msgID + "\r\n"
+	if references != "" {
+		raw += "References: " + references + "\r\n"
+	}
+	raw += "Subject: " + subject + "\r\nDate: " + sentAt.Format(time.RFC1123Z) + "\r\n\r\nbody\r\n"
+	return Message{Seq: seq, UID: seq, RawMessage: raw, DateSent: sentAt.Format(time.RFC1123Z)}
+}
+
+func TestBuildThreadsReferencesChain(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	msgs := []Message{
+		msg(1, "<a@x>", "", "hello", base),
+		msg(2, "<b@x>", "<a@x>", "Re: hello", base.Add(time.Hour)),
+		msg(3, "<c@x>", "<a@x> <b@x>", "Re: hello", base.Add(2*time.Hour)),
+	}
+
+	roots := BuildThreads(msgs, "REFERENCES", false)
+	if len(roots) != 1 {
+		t.Fatalf("want 1 root, got %d", len(roots))
+	}
+	if roots[0].Num != 1 || len(roots[0].Children) != 1 {
+		t.Fatalf("root = %+v, want seq 1 with one child", roots[0])
+	}
+	child := roots[0].Children[0]
+	if child.Num != 2 || len(child.Children) != 1 || child.Children[0].Num != 3 {
+		t.Fatalf("unexpected chain under root: %+v", child)
+	}
+}
+
+func TestBuildThreadsPrunesAbsentIntermediate(t *testing.T) {
+	// b references a (present) and m (never fetched/matched); c replies to
+	// b. The container for "m" should be pruned rather than stranding c's
+	// ancestor chain or showing up as a phantom message.
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	msgs := []Message{
+		msg(1, "<a@x>", "", "hello", base),
+		msg(2, "<b@x>", "<a@x> <m@x>", "Re: hello", base.Add(time.Hour)),
+		msg(3, "<c@x>", "<a@x> <m@x> <b@x>", "Re: hello", base.Add(2*time.Hour)),
+	}
+
+	roots := BuildThreads(msgs, "REFERENCES", false)
+	if len(roots) != 1 || roots[0].Num != 1 {
+		t.Fatalf("want single root seq 1, got %+v", roots)
+	}
+	if len(roots[0].Children) != 1 || roots[0].Children[0].Num != 2 {
+		t.Fatalf("want seq 2 as root's only child, got %+v", roots[0].Children)
+	}
+	grandchildren := roots[0].Children[0].Children
+	if len(grandchildren) != 1 || grandchildren[0].Num != 3 {
+		t.Fatalf("want seq 3 threaded under seq 2, got %+v", grandchildren)
+	}
+}
+
+func TestBuildThreadsBreaksReferenceCycle(t *testing.T) {
+	// a references b and b references a: without cycle detection this
+	// would either infinite-loop or silently drop one message.
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	msgs := []Message{
+		msg(1, "<a@x>", "<b@x>", "loop", base),
+		msg(2, "<b@x>", "<a@x>", "loop", base.Add(time.Hour)),
+	}
+
+	roots := BuildThreads(msgs, "REFERENCES", false)
+	var total int
+	var walk func([]*ThreadNode)
+	walk = func(nodes []*ThreadNode) {
+		for _, n := range nodes {
+			total++
+			walk(n.Children)
+		}
+	}
+	walk(roots)
+	if total != 2 {
+		t.Fatalf("want both messages present exactly once, got %d nodes from roots %+v", total, roots)
+	}
+}
+
+func TestBuildThreadsMergesRootsBySubject(t *testing.T) {
+	// Two independent threads sharing a base subject, with no References
+	// chain connecting them, still end up as one thread (RFC 5256 2.2's
+	// final subject-gathering step).
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	msgs := []Message{
+		msg(1, "<a@x>", "", "budget", base),
+		msg(2, "<b@x>", "", "Re: budget", base.Add(time.Hour)),
+	}
+
+	roots := BuildThreads(msgs, "REFERENCES", false)
+	if len(roots) != 1 {
+		t.Fatalf("want 1 merged root, got %d: %+v", len(roots), roots)
+	}
+	if roots[0].Num != 1 || len(roots[0].Children) != 1 || roots[0].Children[0].Num != 2 {
+		t.Fatalf("want seq 2 merged under seq 1, got %+v", roots[0])
+	}
+}
+
+func TestRenderThread(t *testing.T) {
+	tree := &ThreadNode{
+		Num: 1,
+		Children: []*ThreadNode{
+			{Num: 2},
+			{Num: 3, Children: []*ThreadNode{{Num: 4}}},
+		},
+	}
+	got := RenderThread(tree)
+	want := "1 (2)(3 4)"
+	if got != want {
+		t.Fatalf("RenderThread() = %q, want %q", got, want)
+	}
+}